@@ -3,30 +3,57 @@ package main
 import (
 	"errors"
 	"flag"
-	"log"
 	"fmt"
-	"time"
+	"io"
+	"io/ioutil"
+	"log"
 	"miyatama/ostrichdev/ostrich"
 	"miyatama/ostrichdev/ostrich/web"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/hashicorp/logutils"
 	"net/http"
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	outputInfo("start ostrich-development")
 
+	// subcommand
+	if len(os.Args) > 1 && os.Args[1] == "apply" {
+		os.Exit(applyCommand(os.Args[2:]))
+	}
+
 	// parsing args
 	var (
-		behavior = flag.String("behavior", "standalone", "standalone or web")
-		repository    = flag.String("repository", "", "repository url.ex)https://github.com/xxx/yyy.git")
-		fromBranch    = flag.String("from-branch", "", "committed branch name")
-		commitId      = flag.String("commit-id", "", "commit id")
-		ostrichBranch = flag.String("ostrich-branch", "", "ostrich repository.")
-		logLevel      = flag.String("log-level", "WARN", "log level.DEBUG, INFO, WARN, ERROR")
-		port          = flag.Int("port", 8080, "ostrich service web port")
+		behavior             = flag.String("behavior", "standalone", "standalone or web")
+		repository           = flag.String("repository", "", "repository url.ex)https://github.com/xxx/yyy.git")
+		fromBranch           = flag.String("from-branch", "", "committed branch name")
+		commitId             = flag.String("commit-id", "", "commit id")
+		commitRange          = flag.String("commit-range", "", "commit range.ex)A..B")
+		ostrichBranch        = flag.String("ostrich-branch", "", "ostrich repository.")
+		committerName        = flag.String("committer-name", "", "ostrich commit committer name.default is git config")
+		committerEmail       = flag.String("committer-email", "", "ostrich commit committer email.must be used with committer name")
+		dryRun               = flag.Bool("dry-run", false, "apply without commit and push.print ostrich diff")
+		accumulate           = flag.Bool("accumulate", false, "apply diff to ostrich branch files with earlier comments")
+		workspaceRoot        = flag.String("workspace-root", "", "directory of job workspaces.default is temp directory")
+		workspaceCleanup     = flag.String("workspace-cleanup", "always", "workspace cleanup.always, success or never")
+		logLevel             = flag.String("log-level", "WARN", "log level.DEBUG, INFO, WARN, ERROR")
+		port                 = flag.Int("port", 8080, "ostrich service web port")
+		githubSecret         = flag.String("github-secret", os.Getenv("OSTRICH_GITHUB_SECRET"), "github webhook secret.default is OSTRICH_GITHUB_SECRET")
+		gitlabSecret         = flag.String("gitlab-secret", os.Getenv("OSTRICH_GITLAB_SECRET"), "gitlab webhook secret token.default is OSTRICH_GITLAB_SECRET")
+		giteaSecret          = flag.String("gitea-secret", os.Getenv("OSTRICH_GITEA_SECRET"), "gitea or gogs webhook secret.default is OSTRICH_GITEA_SECRET")
+		bitbucketSecret      = flag.String("bitbucket-secret", os.Getenv("OSTRICH_BITBUCKET_SECRET"), "bitbucket server webhook secret.default is OSTRICH_BITBUCKET_SECRET")
+		bitbucketCloneURL    = flag.String("bitbucket-clone-url", "", "bitbucket server clone url.ex)https://bitbucket.example.com/scm/{PROJECT}/{REPOSITORY}.git")
+		webhookBranches      = flag.String("webhook-branches", "", "comma separated glob of webhook branches.ex)master,release/*")
+		webhookOstrichBranch = flag.String("webhook-ostrich-branch", "ostrich-{BRANCH}", "ostrich branch of webhook.{BRANCH} is pushed branch")
+		jobStore             = flag.String("job-store", "ostrich-jobs.db", "job store file of web")
+		queueSize            = flag.Int("queue-size", 100, "max queued jobs of web")
+		queueRetryAfter      = flag.Int("queue-retry-after", 60, "Retry-After seconds when job queue is full")
+		workers              = flag.Int("workers", 4, "parallel jobs of web.jobs of same repository and ostrich branch run in order")
 	)
 
 	flag.Parse()
@@ -34,97 +61,522 @@ func main() {
 	outputInfo(fmt.Sprintf("\trepository: %s", *repository))
 	outputInfo(fmt.Sprintf("\tfromBranch: %s", *fromBranch))
 	outputInfo(fmt.Sprintf("\tcommitId: %s", *commitId))
+	outputInfo(fmt.Sprintf("\tcommitRange: %s", *commitRange))
 	outputInfo(fmt.Sprintf("\tostrichBranch: %s", *ostrichBranch))
+	outputInfo(fmt.Sprintf("\tcommitterName: %s", *committerName))
+	outputInfo(fmt.Sprintf("\tcommitterEmail: %s", *committerEmail))
+	outputInfo(fmt.Sprintf("\tdryRun: %t", *dryRun))
+	outputInfo(fmt.Sprintf("\taccumulate: %t", *accumulate))
+	outputInfo(fmt.Sprintf("\tworkspaceRoot: %s", *workspaceRoot))
+	outputInfo(fmt.Sprintf("\tworkspaceCleanup: %s", *workspaceCleanup))
 	outputInfo(fmt.Sprintf("\tlogLevel: %s", *logLevel))
 	outputInfo(fmt.Sprintf("\tport: %d", *port))
+	outputInfo(fmt.Sprintf("\twebhookBranches: %s", *webhookBranches))
+	outputInfo(fmt.Sprintf("\twebhookOstrichBranch: %s", *webhookOstrichBranch))
+	outputInfo(fmt.Sprintf("\tbitbucketCloneURL: %s", *bitbucketCloneURL))
+	outputInfo(fmt.Sprintf("\tjobStore: %s", *jobStore))
+	outputInfo(fmt.Sprintf("\tqueueSize: %d", *queueSize))
+	outputInfo(fmt.Sprintf("\tqueueRetryAfter: %d", *queueRetryAfter))
+	outputInfo(fmt.Sprintf("\tworkers: %d", *workers))
 
-	// setting log level
-	filter := &logutils.LevelFilter{
-		Levels:   []logutils.LogLevel{"DEBUG", "INFO", "WARN", "ERROR"},
-		MinLevel: logutils.LogLevel(*logLevel),
-		Writer:   os.Stderr,
+	setLogLevel(*logLevel)
+	if err := ostrich.ValidateWorkspaceCleanup(*workspaceCleanup); err != nil {
+		outputError(err)
+		os.Exit(1)
+	}
+	committer := ostrich.CommitIdentity{
+		Name:  *committerName,
+		Email: *committerEmail,
+	}
+	if err := ostrich.ValidateCommitter(committer); err != nil {
+		outputError(err)
+		os.Exit(1)
+	}
+	settings := ostrichSettings{
+		Committer:        committer,
+		WorkspaceRoot:    *workspaceRoot,
+		WorkspaceCleanup: *workspaceCleanup,
 	}
-	log.SetOutput(filter)
 
-	switch(*behavior){
+	switch *behavior {
 	case "standalone":
-		err := callOstrich(*repository , *fromBranch , *ostrichBranch , *commitId)
+		result, err := callOstrich(web.OstrichWebRequest{
+			Repository:    *repository,
+			FromBranch:    *fromBranch,
+			CommitID:      *commitId,
+			CommitRange:   *commitRange,
+			OstrichBranch: *ostrichBranch,
+			DryRun:        *dryRun,
+			Accumulate:    *accumulate,
+		}, settings)
 		if err != nil {
 			outputError(err)
 		}
+		for _, dryRunResult := range result.DryRunResults {
+			fmt.Println(strings.Join(dryRunResult.Diff, "\n"))
+		}
 		break
 	case "web":
-		requests := make(chan web.WebRequest)
-		go func() {
-			for ;; {
-				request := <-requests
-				switch(request.Action) {
-				case web.WebRequestActionOstrich:
-					// wait a 3 times
-					for i := 0; i < 3; i++ {
-						err := callOstrich(
-							request.Info.Repository,
-							request.Info.FromBranch,
-							request.Info.OstrichBranch,
-							request.Info.CommitID)
-						if err != nil {
-							outputError(err)
-							time.Sleep(10 * time.Second)
-						} else {
-							break
-						}
-					}
-				case web.WebRequestActionDone:
-					return
-				}
-			}
-		}()
+		if *queueSize <= 0 {
+			outputError(fmt.Errorf("invalid queue size %d", *queueSize))
+			os.Exit(1)
+		}
+		if *workers <= 0 {
+			outputError(fmt.Errorf("invalid workers %d", *workers))
+			os.Exit(1)
+		}
+		store, err := web.OpenJobStore(*jobStore)
+		if err != nil {
+			outputError(err)
+			os.Exit(1)
+		}
+		// unfinished jobs of last run are queued before new requests, so queue has space for all of them
+		backlog, err := unfinishedRequests(store)
+		if err != nil {
+			outputError(err)
+			os.Exit(1)
+		}
+		size := *queueSize
+		if size < len(backlog) {
+			size = len(backlog)
+		}
+		intake := &jobIntake{
+			queue:      web.NewJobQueue(size),
+			store:      store,
+			retryAfter: *queueRetryAfter,
+		}
+		if err := intake.queue.Push(backlog...); err != nil {
+			outputError(err)
+			os.Exit(1)
+		}
+		pool := &web.WorkerPool{
+			Workers: *workers,
+			Handle: jobHandler(store, func(info web.OstrichWebRequest) (ostrichResult, error) {
+				return callOstrich(info, settings)
+			}),
+			Started: intake.queue.Started,
+		}
+		go pool.Run(intake.queue.Requests())
 
 		rest := gin.Default()
 
-		callOstrichWeb := func (c *gin.Context) {
-			body := web.OstrichWebRequest{}
-			c.Bind(&body)
-
-			requests <- web.WebRequest{
-				Action: web.WebRequestActionOstrich,
-				Info: body,
-			}
-			result := web.OstrichWebResponse{}
-			status := http.StatusOK
-			c.JSON(status, result)
+		rest.POST("/ostrich", callOstrichWeb(intake))
+		rest.GET("/jobs", listJobs(store))
+		rest.GET("/jobs/:id", getJob(store))
+		rest.GET("/queue", func(c *gin.Context) {
+			c.JSON(http.StatusOK, intake.queue.Status())
+		})
+		rest.GET("/workers", func(c *gin.Context) {
+			c.JSON(http.StatusOK, pool.Status())
+		})
+		webhookConfig := web.WebhookConfig{
+			Branches:      splitList(*webhookBranches),
+			OstrichBranch: *webhookOstrichBranch,
+			Accumulate:    *accumulate,
+		}
+		webhookSecrets := map[string]string{
+			"github":    *githubSecret,
+			"gitlab":    *gitlabSecret,
+			"gitea":     *giteaSecret,
+			"bitbucket": *bitbucketSecret,
+		}
+		webhookReceivers := map[string]web.WebhookReceiver{
+			"github":    web.GitHubReceiver{},
+			"gitlab":    web.GitLabReceiver{},
+			"gitea":     web.GiteaReceiver{},
+			"bitbucket": web.BitbucketReceiver{CloneURL: *bitbucketCloneURL},
+		}
+		for name, receiver := range webhookReceivers {
+			config := webhookConfig
+			config.Secret = webhookSecrets[name]
+			rest.POST("/webhooks/"+name, webhook(intake, config, receiver))
 		}
-		rest.POST("/ostrich", callOstrichWeb)
 		rest.Run(fmt.Sprintf(":%d", *port))
 		break
 	}
 	os.Exit(0)
 }
 
-func callOstrich(repository string, fromBranch string, ostrichBranch string, commitId string) error{
-	outputInfo(fmt.Sprintf("\trepository: %s", repository))
-	outputInfo(fmt.Sprintf("\tfromBranch: %s", fromBranch))
-	outputInfo(fmt.Sprintf("\tcommitId: %s", commitId))
-	outputInfo(fmt.Sprintf("\tostrichBranch: %s", ostrichBranch))
-	if err := HasArgsError(repository, fromBranch, commitId, ostrichBranch); err != nil {
-		return err
+// callOstrichWeb is handler of ostrich request.invalid request is 400 without job.
+// response does not wait for job, and diffs of dry run are in GET /jobs/:id.
+func callOstrichWeb(intake *jobIntake) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := web.OstrichWebRequest{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		if err := HasArgsError(body.Repository, body.FromBranch, requestCommitIDs(body), body.CommitRange, body.OstrichBranch); err != nil {
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+
+		jobIDs, err := intake.enqueue([]web.OstrichWebRequest{body})
+		if err != nil {
+			intake.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, web.OstrichWebResponse{JobID: jobIDs[0]})
+	}
+}
+
+// webhook is handler of push webhook of git hosting service.push of filtered branch is not processed.
+func webhook(intake *jobIntake, config web.WebhookConfig, receiver web.WebhookReceiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		if err := receiver.Verify(config.Secret, c.Request.Header, body); err != nil {
+			outputError(err)
+			c.JSON(http.StatusUnauthorized, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		events, err := receiver.Parse(c.Request.Header, body)
+		if errors.Is(err, web.ErrEventIgnored) {
+			c.JSON(http.StatusOK, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		requests, messages := newPushRequests(config, events)
+		response := web.OstrichWebResponse{Message: strings.Join(messages, "\n")}
+		if len(requests) <= 0 {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+		response.JobIDs, err = intake.enqueue(requests)
+		if err != nil {
+			intake.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, response)
+	}
+}
+
+// newPushRequests is return ostrich requests of pushes and messages of skipped pushes.
+func newPushRequests(config web.WebhookConfig, events []web.PushEvent) ([]web.OstrichWebRequest, []string) {
+	requests := []web.OstrichWebRequest{}
+	messages := []string{}
+	for _, event := range events {
+		request, err := config.NewOstrichWebRequest(event)
+		if err != nil {
+			if errors.Is(err, web.ErrPushSkipped) {
+				outputInfo(err.Error())
+			} else {
+				outputError(err)
+			}
+			messages = append(messages, err.Error())
+			continue
+		}
+		requests = append(requests, request)
+	}
+	return requests, messages
+}
+
+// splitList is return comma separated values without empty value.
+func splitList(text string) []string {
+	result := []string{}
+	for _, value := range strings.Split(text, ",") {
+		value = strings.TrimSpace(value)
+		if len(value) > 0 {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// jobRetryCount is attempts of failed job, and jobRetryInterval is wait between attempts.
+var (
+	jobRetryCount    = 3
+	jobRetryInterval = 10 * time.Second
+)
+
+// jobIntake is adding jobs of web requests to store and queue without waiting for worker.
+type jobIntake struct {
+	queue      *web.JobQueue
+	store      *web.JobStore
+	retryAfter int // seconds of Retry-After when queue is full
+}
+
+// enqueue is create queued jobs of requests and add them to queue, and return job ids.
+// all jobs are removed when queue has no space for them.
+func (i *jobIntake) enqueue(infos []web.OstrichWebRequest) ([]string, error) {
+	jobIDs := []string{}
+	requests := []web.WebRequest{}
+	for _, info := range infos {
+		job, err := i.store.Create(info)
+		if err != nil {
+			i.removeJobs(jobIDs)
+			return []string{}, err
+		}
+		jobIDs = append(jobIDs, job.ID)
+		requests = append(requests, web.WebRequest{
+			Action: web.WebRequestActionOstrich,
+			JobID:  job.ID,
+			Info:   info,
+		})
+	}
+	if err := i.queue.Push(requests...); err != nil {
+		i.removeJobs(jobIDs)
+		return []string{}, err
+	}
+	return jobIDs, nil
+}
+
+func (i *jobIntake) removeJobs(jobIDs []string) {
+	for _, jobID := range jobIDs {
+		if err := i.store.Delete(jobID); err != nil {
+			outputError(err)
+		}
+	}
+}
+
+// respondError is response of enqueue error.full queue is 503 with Retry-After.
+func (i *jobIntake) respondError(c *gin.Context, err error) {
+	outputError(err)
+	if errors.Is(err, web.ErrQueueFull) {
+		c.Header("Retry-After", strconv.Itoa(i.retryAfter))
+		c.JSON(http.StatusServiceUnavailable, web.OstrichWebResponse{Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, web.OstrichWebResponse{Message: err.Error()})
+}
+
+// unfinishedRequests is return requests of unfinished jobs of last run in created order.running job is queued again.
+func unfinishedRequests(store *web.JobStore) ([]web.WebRequest, error) {
+	jobs, err := store.Unfinished()
+	if err != nil {
+		return nil, err
+	}
+	requests := []web.WebRequest{}
+	for _, job := range jobs {
+		if _, err := store.Update(job.ID, func(job *web.Job) { job.State = web.JobStateQueued }); err != nil {
+			return nil, err
+		}
+		outputInfo(fmt.Sprintf("requeue job %s", job.ID))
+		requests = append(requests, web.WebRequest{
+			Action: web.WebRequestActionOstrich,
+			JobID:  job.ID,
+			Info:   job.Request,
+		})
+	}
+	return requests, nil
+}
+
+// jobHandler is return handler of worker pool which runs job.result is in job store.
+func jobHandler(store *web.JobStore, run func(web.OstrichWebRequest) (ostrichResult, error)) func(web.WebRequest) {
+	return func(request web.WebRequest) {
+		runJob(store, request, run)
+	}
+}
+
+// runJob is run ostrich of job with retry and record state, attempts, error and ostrich commit.
+func runJob(store *web.JobStore, request web.WebRequest, run func(web.OstrichWebRequest) (ostrichResult, error)) web.OstrichWebResponse {
+	updateJob := func(update func(job *web.Job)) {
+		if _, err := store.Update(request.JobID, update); err != nil {
+			outputError(err)
+		}
+	}
+	response := web.OstrichWebResponse{JobID: request.JobID}
+	for i := 0; i < jobRetryCount; i++ {
+		if i > 0 {
+			time.Sleep(jobRetryInterval)
+		}
+		updateJob(func(job *web.Job) {
+			job.State = web.JobStateRunning
+			job.Attempts++
+		})
+		result, err := run(request.Info)
+		if err != nil {
+			outputError(err)
+			response.Message = err.Error()
+			updateJob(func(job *web.Job) { job.Error = err.Error() })
+			continue
+		}
+		response = newOstrichWebResponse(result.DryRunResults)
+		response.JobID = request.JobID
+		updateJob(func(job *web.Job) {
+			job.State = web.JobStateSucceeded
+			job.Error = ""
+			job.OstrichCommitID = result.OstrichCommitID
+			job.Diffs = response.Diffs
+		})
+		return response
+	}
+	updateJob(func(job *web.Job) { job.State = web.JobStateFailed })
+	return response
+}
+
+// getJob is handler of job of id.
+func getJob(store *web.JobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, err := store.Get(c.Param("id"))
+		if errors.Is(err, web.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			outputError(err)
+			c.JSON(http.StatusInternalServerError, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// listJobs is handler of jobs from newest.query is state and limit.default limit is 100.
+func listJobs(store *web.JobStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := web.JobState(c.Query("state"))
+		switch state {
+		case "", web.JobStateQueued, web.JobStateRunning, web.JobStateSucceeded, web.JobStateFailed:
+		default:
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: fmt.Sprintf("invalid state %s", state)})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, web.OstrichWebResponse{Message: fmt.Sprintf("invalid limit %s", c.Query("limit"))})
+			return
+		}
+		jobs, err := store.List(state, limit)
+		if err != nil {
+			outputError(err)
+			c.JSON(http.StatusInternalServerError, web.OstrichWebResponse{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, web.JobListResponse{Jobs: jobs})
+	}
+}
+
+// applyCommand is apply ostrich comments of patch to local files.
+// usage: ostrichdev apply -patch changes.patch -dir .
+func applyCommand(args []string) int {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	var (
+		patch    = flags.String("patch", "-", "git show, git format-patch or git diff output file.- is stdin")
+		dir      = flags.String("dir", ".", "patched working tree directory")
+		logLevel = flags.String("log-level", "WARN", "log level.DEBUG, INFO, WARN, ERROR")
+	)
+	flags.Parse(args)
+	setLogLevel(*logLevel)
+	outputInfo(fmt.Sprintf("\tpatch: %s", *patch))
+	outputInfo(fmt.Sprintf("\tdir: %s", *dir))
+
+	texts, err := readPatch(*patch, os.Stdin)
+	if err != nil {
+		outputError(err)
+		return 1
+	}
+	o := ostrich.Ostrich{
+		FileAccessor: &ostrich.FileAccesser{},
+	}
+	if err := o.ApplyPatch(*dir, texts); err != nil {
+		outputError(err)
+		return 1
+	}
+	return 0
+}
+
+// readPatch is return lines of patch file.- is reading stdin.
+func readPatch(path string, stdin io.Reader) ([]string, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = ioutil.ReadAll(stdin)
+	} else {
+		content, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"), nil
+}
+
+func setLogLevel(logLevel string) {
+	filter := &logutils.LevelFilter{
+		Levels:   []logutils.LogLevel{"DEBUG", "INFO", "WARN", "ERROR"},
+		MinLevel: logutils.LogLevel(logLevel),
+		Writer:   os.Stderr,
+	}
+	log.SetOutput(filter)
+}
+
+// ostrichSettings is process level setting of ostrich jobs.
+type ostrichSettings struct {
+	Committer        ostrich.CommitIdentity
+	WorkspaceRoot    string
+	WorkspaceCleanup string
+}
+
+// ostrichResult is result of ostrich job.
+type ostrichResult struct {
+	OstrichCommitID string
+	DryRunResults   []ostrich.DryRunResult
+}
+
+func callOstrich(info web.OstrichWebRequest, settings ostrichSettings) (ostrichResult, error) {
+	outputInfo(fmt.Sprintf("\trepository: %s", info.Repository))
+	outputInfo(fmt.Sprintf("\tfromBranch: %s", info.FromBranch))
+	outputInfo(fmt.Sprintf("\tcommitId: %s", info.CommitID))
+	outputInfo(fmt.Sprintf("\tcommitIds: %s", strings.Join(info.CommitIDs, ",")))
+	outputInfo(fmt.Sprintf("\tcommitRange: %s", info.CommitRange))
+	outputInfo(fmt.Sprintf("\tostrichBranch: %s", info.OstrichBranch))
+	outputInfo(fmt.Sprintf("\tdryRun: %t", info.DryRun))
+	outputInfo(fmt.Sprintf("\taccumulate: %t", info.Accumulate))
+	commitIDs := requestCommitIDs(info)
+	if err := HasArgsError(info.Repository, info.FromBranch, commitIDs, info.CommitRange, info.OstrichBranch); err != nil {
+		return ostrichResult{}, err
 	}
 
 	ostrich := ostrich.Ostrich{
-		Repository:    repository,
-		FromBranch:    fromBranch,
-		OstrichBranch: ostrichBranch,
-		CommitId:      commitId,
-		FileAccessor:  &ostrich.FileAccesser{},
+		Repository:       info.Repository,
+		FromBranch:       info.FromBranch,
+		OstrichBranch:    info.OstrichBranch,
+		CommitIds:        commitIDs,
+		CommitRange:      info.CommitRange,
+		FileAccessor:     &ostrich.FileAccesser{},
+		Committer:        settings.Committer,
+		WorkspaceRoot:    settings.WorkspaceRoot,
+		WorkspaceCleanup: settings.WorkspaceCleanup,
+		DryRun:           info.DryRun,
+		Accumulate:       info.Accumulate,
 	}
 
 	// call ostrich
 	if err := ostrich.Run(); err != nil {
-		return err
+		return ostrichResult{}, err
 	}
-	return nil
+	return ostrichResult{
+		OstrichCommitID: ostrich.OstrichCommitId,
+		DryRunResults:   ostrich.DryRunResults,
+	}, nil
 }
 
+// requestCommitIDs is return commitId and commitIds of request.
+func requestCommitIDs(info web.OstrichWebRequest) []string {
+	commitIDs := []string{}
+	if len(info.CommitID) > 0 {
+		commitIDs = append(commitIDs, info.CommitID)
+	}
+	return append(commitIDs, info.CommitIDs...)
+}
+
+// newOstrichWebResponse is return response with dry run diffs.
+func newOstrichWebResponse(results []ostrich.DryRunResult) web.OstrichWebResponse {
+	response := web.OstrichWebResponse{}
+	for _, result := range results {
+		response.Diffs = append(response.Diffs, web.OstrichWebDiff{
+			CommitID: result.CommitID,
+			Diff:     strings.Join(result.Diff, "\n"),
+		})
+	}
+	return response
+}
 
 func outputError(err error) {
 	log.Printf("[ERROR]: %s", err.Error())
@@ -134,15 +586,21 @@ func outputInfo(message string) {
 	log.Printf("[INFO]: %s", message)
 }
 
-func HasArgsError(repository, fromBrancch, commitID, ostrichBranch string) error {
+func HasArgsError(repository, fromBrancch string, commitIDs []string, commitRange, ostrichBranch string) error {
 	if len(repository) <= 0 {
 		return errors.New("repository is must need argus")
 	}
 	if len(fromBrancch) <= 0 {
 		return errors.New("from branch is must need argus")
 	}
-	if len(commitID) <= 0 {
-		return errors.New("commit id is must need argus")
+	if len(commitIDs) <= 0 && len(commitRange) <= 0 {
+		return errors.New("commit id or commit range is must need argus")
+	}
+	if len(commitIDs) > 0 && len(commitRange) > 0 {
+		return errors.New("commit id and commit range can not use together")
+	}
+	if len(commitRange) > 0 && !strings.Contains(commitRange, "..") {
+		return fmt.Errorf("invalid commit range %s.format is A..B", commitRange)
 	}
 	if len(ostrichBranch) <= 0 {
 		return errors.New("ostrich branch is must need argus")
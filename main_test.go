@@ -1,16 +1,34 @@
 package main
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"miyatama/ostrichdev/ostrich"
+	"miyatama/ostrichdev/ostrich/web"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/gin-gonic/gin"
 )
 
 func TestHasArgsError(t *testing.T) {
 	t.Run("repository is empty", func(t *testing.T) {
 		repository := ""
 		fromBrancch := ""
-		commitID := ""
+		commitIDs := []string{}
+		commitRange := ""
 		ostrichBranch := ""
-		err := HasArgsError(&repository, &fromBrancch, &commitID, &ostrichBranch)
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
 		if err == nil {
 			t.Fatal("can not get error.")
 		}
@@ -18,9 +36,10 @@ func TestHasArgsError(t *testing.T) {
 	t.Run("from branch is empty", func(t *testing.T) {
 		repository := "http://miyata.com"
 		fromBrancch := ""
-		commitID := ""
+		commitIDs := []string{}
+		commitRange := ""
 		ostrichBranch := ""
-		err := HasArgsError(&repository, &fromBrancch, &commitID, &ostrichBranch)
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
 		if err == nil {
 			t.Fatal("can not get error.")
 		}
@@ -28,9 +47,10 @@ func TestHasArgsError(t *testing.T) {
 	t.Run("commit id is empty", func(t *testing.T) {
 		repository := "http://miyata.com"
 		fromBrancch := "master"
-		commitID := ""
+		commitIDs := []string{}
+		commitRange := ""
 		ostrichBranch := ""
-		err := HasArgsError(&repository, &fromBrancch, &commitID, &ostrichBranch)
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
 		if err == nil {
 			t.Fatal("can not get error.")
 		}
@@ -38,9 +58,32 @@ func TestHasArgsError(t *testing.T) {
 	t.Run("ostrich branch is empty", func(t *testing.T) {
 		repository := "http://miyata.com"
 		fromBrancch := "master"
-		commitID := "kfj;alkefja"
+		commitIDs := []string{"kfj;alkefja"}
+		commitRange := ""
 		ostrichBranch := ""
-		err := HasArgsError(&repository, &fromBrancch, &commitID, &ostrichBranch)
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
+		if err == nil {
+			t.Fatal("can not get error.")
+		}
+	})
+	t.Run("commit id and commit range", func(t *testing.T) {
+		repository := "http://miyata.com"
+		fromBrancch := "master"
+		commitIDs := []string{"kfj;alkefja"}
+		commitRange := "aaaaaaa..bbbbbbb"
+		ostrichBranch := "ostrich"
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
+		if err == nil {
+			t.Fatal("can not get error.")
+		}
+	})
+	t.Run("invalid commit range", func(t *testing.T) {
+		repository := "http://miyata.com"
+		fromBrancch := "master"
+		commitIDs := []string{}
+		commitRange := "aaaaaaa"
+		ostrichBranch := "ostrich"
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
 		if err == nil {
 			t.Fatal("can not get error.")
 		}
@@ -48,11 +91,414 @@ func TestHasArgsError(t *testing.T) {
 	t.Run("all cleear", func(t *testing.T) {
 		repository := "http://miyata.com"
 		fromBrancch := "master"
-		commitID := "kfj;alkefja"
+		commitIDs := []string{"kfj;alkefja"}
+		commitRange := ""
+		ostrichBranch := "ostrich"
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
+		if err != nil {
+			t.Fatalf("return error.%#v", err)
+		}
+	})
+	t.Run("all cleear with commit range", func(t *testing.T) {
+		repository := "http://miyata.com"
+		fromBrancch := "master"
+		commitIDs := []string{}
+		commitRange := "aaaaaaa..bbbbbbb"
 		ostrichBranch := "ostrich"
-		err := HasArgsError(&repository, &fromBrancch, &commitID, &ostrichBranch)
+		err := HasArgsError(repository, fromBrancch, commitIDs, commitRange, ostrichBranch)
 		if err != nil {
 			t.Fatalf("return error.%#v", err)
 		}
 	})
 }
+
+func TestNewOstrichWebResponse(t *testing.T) {
+	results := []ostrich.DryRunResult{
+		{CommitID: "1111111", Diff: []string{"diff --git a/main.go b/main.go", "+// ADD START"}},
+	}
+	response := newOstrichWebResponse(results)
+	if len(response.Diffs) != 1 {
+		t.Fatalf("invalid diffs length %d", len(response.Diffs))
+	}
+	if response.Diffs[0].CommitID != "1111111" {
+		t.Fatalf("invalid commit id %s", response.Diffs[0].CommitID)
+	}
+	if response.Diffs[0].Diff != "diff --git a/main.go b/main.go\n+// ADD START" {
+		t.Fatalf("invalid diff %s", response.Diffs[0].Diff)
+	}
+}
+
+func TestReadPatch(t *testing.T) {
+	t.Run("stdin", func(t *testing.T) {
+		texts, err := readPatch("-", strings.NewReader("diff --git a/main.go b/main.go\nindex 1..2\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(texts) != 2 || texts[1] != "index 1..2" {
+			t.Fatalf("invalid texts %#v", texts)
+		}
+	})
+	t.Run("file", func(t *testing.T) {
+		texts, err := readPatch("testdata/format_patch_text.txt", strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if !strings.HasPrefix(texts[0], "From ") {
+			t.Fatalf("invalid texts %#v", texts[0])
+		}
+	})
+	t.Run("file not found", func(t *testing.T) {
+		if _, err := readPatch("testdata/not_found.txt", strings.NewReader("")); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body, err := ioutil.ReadFile("testdata/github_push_event.json")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	sign := func(secret string, body []byte) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	store, cleanup := openTestJobStore(t)
+	defer cleanup()
+	config := web.WebhookConfig{
+		Secret:        "secret",
+		Branches:      []string{"master"},
+		OstrichBranch: "ostrich-{BRANCH}",
+	}
+	intake := &jobIntake{
+		queue:      web.NewJobQueue(1),
+		store:      store,
+		retryAfter: 30,
+	}
+	post := func(event string, signature string, config web.WebhookConfig) (*httptest.ResponseRecorder, []web.WebRequest) {
+		rest := gin.New()
+		rest.POST("/webhooks/github", webhook(intake, config, web.GitHubReceiver{}))
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+		request.Header.Set(web.GitHubEventHeader, event)
+		request.Header.Set(web.GitHubSignatureHeader, signature)
+		recorder := httptest.NewRecorder()
+		rest.ServeHTTP(recorder, request)
+		result := []web.WebRequest{}
+		for intake.queue.Status().Depth > 0 {
+			result = append(result, <-intake.queue.Requests())
+			intake.queue.Started()
+		}
+		return recorder, result
+	}
+	t.Run("push event", func(t *testing.T) {
+		recorder, requests := post("push", sign("secret", body), config)
+		if recorder.Code != http.StatusAccepted {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		if len(requests) != 1 {
+			t.Fatalf("invalid requests %#v", requests)
+		}
+		info := requests[0].Info
+		if info.Repository != "https://github.com/miyatama/sample.git" || info.FromBranch != "master" || info.OstrichBranch != "ostrich-master" {
+			t.Fatalf("invalid request %#v", info)
+		}
+		if info.CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" {
+			t.Fatalf("invalid commit range %s", info.CommitRange)
+		}
+		response := web.OstrichWebResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response %s", recorder.Body.String())
+		}
+		if len(response.JobIDs) != 1 || response.JobIDs[0] != requests[0].JobID {
+			t.Fatalf("invalid job ids %#v", response.JobIDs)
+		}
+		job, err := store.Get(requests[0].JobID)
+		if err != nil || job.State != web.JobStateQueued {
+			t.Fatalf("invalid job %#v, %#v", job, err)
+		}
+	})
+	t.Run("queue is full", func(t *testing.T) {
+		if err := intake.queue.Push(web.WebRequest{}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		defer func() {
+			<-intake.queue.Requests()
+			intake.queue.Started()
+		}()
+		rest := gin.New()
+		rest.POST("/webhooks/github", webhook(intake, config, web.GitHubReceiver{}))
+		request := httptest.NewRequest(http.MethodPost, "/webhooks/github", bytes.NewReader(body))
+		request.Header.Set(web.GitHubEventHeader, "push")
+		request.Header.Set(web.GitHubSignatureHeader, sign("secret", body))
+		recorder := httptest.NewRecorder()
+		rest.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") != "30" {
+			t.Fatalf("invalid status %d, header %#v", recorder.Code, recorder.Header())
+		}
+		jobs, err := store.List(web.JobStateQueued, 0)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("rejected job is not removed %#v, %#v", jobs, err)
+		}
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		recorder, requests := post("push", sign("other", body), config)
+		if recorder.Code != http.StatusUnauthorized || len(requests) != 0 {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+	})
+	t.Run("filtered branch", func(t *testing.T) {
+		filtered := config
+		filtered.Branches = []string{"release/*"}
+		recorder, requests := post("push", sign("secret", body), filtered)
+		if recorder.Code != http.StatusOK || len(requests) != 0 {
+			t.Fatalf("invalid status %d, requests %#v", recorder.Code, requests)
+		}
+	})
+	t.Run("ping event", func(t *testing.T) {
+		recorder, requests := post("ping", sign("secret", body), config)
+		if recorder.Code != http.StatusOK || len(requests) != 0 {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+	})
+	t.Run("invalid ostrich branch", func(t *testing.T) {
+		same := config
+		same.OstrichBranch = "{BRANCH}"
+		recorder, requests := post("push", sign("secret", body), same)
+		if recorder.Code != http.StatusOK || len(requests) != 0 {
+			t.Fatalf("invalid status %d, requests %#v", recorder.Code, requests)
+		}
+	})
+}
+
+func openTestJobStore(t *testing.T) (*web.JobStore, func()) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	store, err := web.OpenJobStore(filepath.Join(dir, "jobs.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("can not open job store.%#v", err)
+	}
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	store, cleanup := openTestJobStore(t)
+	defer cleanup()
+	interval := jobRetryInterval
+	jobRetryInterval = 0
+	defer func() { jobRetryInterval = interval }()
+
+	t.Run("succeeded job", func(t *testing.T) {
+		job, err := store.Create(web.OstrichWebRequest{DryRun: true})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		calls := 0
+		response := runJob(store, web.WebRequest{JobID: job.ID, Info: job.Request}, func(info web.OstrichWebRequest) (ostrichResult, error) {
+			calls++
+			if calls < 2 {
+				return ostrichResult{}, errors.New("clone error")
+			}
+			return ostrichResult{
+				OstrichCommitID: "2222222",
+				DryRunResults:   []ostrich.DryRunResult{{CommitID: "1111111", Diff: []string{"+// ADD START"}}},
+			}, nil
+		})
+		if response.JobID != job.ID || len(response.Message) > 0 || len(response.Diffs) != 1 {
+			t.Fatalf("invalid response %#v", response)
+		}
+		job, err = store.Get(job.ID)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if job.State != web.JobStateSucceeded || job.Attempts != 2 || job.OstrichCommitID != "2222222" || len(job.Error) > 0 {
+			t.Fatalf("invalid job %#v", job)
+		}
+	})
+	t.Run("failed job", func(t *testing.T) {
+		job, err := store.Create(web.OstrichWebRequest{})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		response := runJob(store, web.WebRequest{JobID: job.ID, Info: job.Request}, func(info web.OstrichWebRequest) (ostrichResult, error) {
+			return ostrichResult{}, errors.New("clone error")
+		})
+		if response.Message != "clone error" {
+			t.Fatalf("invalid response %#v", response)
+		}
+		job, err = store.Get(job.ID)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if job.State != web.JobStateFailed || job.Attempts != jobRetryCount || job.Error != "clone error" {
+			t.Fatalf("invalid job %#v", job)
+		}
+	})
+}
+
+func TestUnfinishedRequests(t *testing.T) {
+	store, cleanup := openTestJobStore(t)
+	defer cleanup()
+	states := []web.JobState{web.JobStateRunning, web.JobStateSucceeded, web.JobStateQueued, web.JobStateFailed}
+	ids := []string{}
+	for i, state := range states {
+		job, err := store.Create(web.OstrichWebRequest{CommitID: fmt.Sprintf("%d", i)})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if _, err := store.Update(job.ID, func(job *web.Job) { job.State = state }); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+
+	requests, err := unfinishedRequests(store)
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if len(requests) != 2 || requests[0].JobID != ids[0] || requests[1].JobID != ids[2] {
+		t.Fatalf("invalid requests %#v", requests)
+	}
+	if requests[0].Action != web.WebRequestActionOstrich || requests[0].Info.CommitID != "0" {
+		t.Fatalf("invalid request %#v", requests[0])
+	}
+	job, err := store.Get(ids[0])
+	if err != nil || job.State != web.JobStateQueued {
+		t.Fatalf("invalid job %#v, %#v", job, err)
+	}
+}
+
+func TestJobHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, cleanup := openTestJobStore(t)
+	defer cleanup()
+	job, err := store.Create(web.OstrichWebRequest{Repository: "https://github.com/x/y.git"})
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	rest := gin.New()
+	rest.GET("/jobs", listJobs(store))
+	rest.GET("/jobs/:id", getJob(store))
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		rest.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	t.Run("get job", func(t *testing.T) {
+		recorder := get("/jobs/" + job.ID)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		result := web.Job{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+			t.Fatalf("invalid response %s", recorder.Body.String())
+		}
+		if result.ID != job.ID || result.State != web.JobStateQueued {
+			t.Fatalf("invalid job %#v", result)
+		}
+	})
+	t.Run("job not found", func(t *testing.T) {
+		if recorder := get("/jobs/999"); recorder.Code != http.StatusNotFound {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+	})
+	t.Run("list jobs", func(t *testing.T) {
+		recorder := get("/jobs?state=queued&limit=10")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		result := web.JobListResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+			t.Fatalf("invalid response %s", recorder.Body.String())
+		}
+		if len(result.Jobs) != 1 || result.Jobs[0].ID != job.ID {
+			t.Fatalf("invalid jobs %#v", result.Jobs)
+		}
+	})
+	t.Run("invalid query", func(t *testing.T) {
+		if recorder := get("/jobs?state=done"); recorder.Code != http.StatusBadRequest {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		if recorder := get("/jobs?limit=x"); recorder.Code != http.StatusBadRequest {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+	})
+}
+
+func TestCallOstrichWeb(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, cleanup := openTestJobStore(t)
+	defer cleanup()
+	intake := &jobIntake{
+		queue:      web.NewJobQueue(1),
+		store:      store,
+		retryAfter: 30,
+	}
+	rest := gin.New()
+	rest.POST("/ostrich", callOstrichWeb(intake))
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/ostrich", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		rest.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("accepted", func(t *testing.T) {
+		recorder := post(`{"repository":"https://github.com/x/y.git","fromBranch":"master","commitId":"1111111","ostrichBranch":"ostrich"}`)
+		if recorder.Code != http.StatusAccepted {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		response := web.OstrichWebResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response %s", recorder.Body.String())
+		}
+		request := <-intake.queue.Requests()
+		intake.queue.Started()
+		if len(response.JobID) <= 0 || request.JobID != response.JobID {
+			t.Fatalf("invalid job id %s, %s", response.JobID, request.JobID)
+		}
+	})
+	t.Run("dry run is accepted without waiting", func(t *testing.T) {
+		recorder := post(`{"repository":"https://github.com/x/y.git","fromBranch":"master","commitId":"1111111","ostrichBranch":"ostrich","dryRun":true}`)
+		if recorder.Code != http.StatusAccepted {
+			t.Fatalf("invalid status %d", recorder.Code)
+		}
+		response := web.OstrichWebResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response %s", recorder.Body.String())
+		}
+		request := <-intake.queue.Requests()
+		intake.queue.Started()
+		if len(response.JobID) <= 0 || request.JobID != response.JobID || !request.Info.DryRun {
+			t.Fatalf("invalid request %#v", request)
+		}
+	})
+	invalidBodies := map[string]string{
+		"malformed json":    `{"repository":`,
+		"no from branch":    `{"repository":"https://github.com/x/y.git","commitId":"1111111","ostrichBranch":"ostrich"}`,
+		"no commit":         `{"repository":"https://github.com/x/y.git","fromBranch":"master","ostrichBranch":"ostrich"}`,
+		"no ostrich branch": `{"repository":"https://github.com/x/y.git","fromBranch":"master","commitId":"1111111"}`,
+	}
+	for name, body := range invalidBodies {
+		t.Run(name, func(t *testing.T) {
+			jobs, _ := store.List("", 0)
+			recorder := post(body)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("invalid status %d", recorder.Code)
+			}
+			after, _ := store.List("", 0)
+			if len(after) != len(jobs) || intake.queue.Status().Depth != 0 {
+				t.Fatalf("job is created %#v", after)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package ostrich
+
+import (
+	"fmt"
+	"sort"
+)
+
+// editOstrichFile is apply comments to source file, or to ostrich file in accumulate mode.
+func (o *Ostrich) editOstrichFile(commit Commit, commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	if o.Accumulate {
+		return o.applyAccumulateEditOstricFile(commit, commentBase, ostrichFileInfo, git)
+	}
+	return o.applyEditOstricFile(commentBase, ostrichFileInfo, git)
+}
+
+// applyAccumulateEditOstricFile is apply diff and comments to ostrich file with comments of earlier commits.
+// lines of old source file are mapped to lines of ostrich file shifted by earlier comments.
+func (o *Ostrich) applyAccumulateEditOstricFile(commit Commit, commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applyAccumulateEditOstricFile")
+	content, err := o.FileAccessor.ReadAll(ostrichFileInfo.Filename)
+	if err != nil {
+		return err
+	}
+	content, err = o.applyLineEndingAttr(content, ostrichFileInfo.Filename, git)
+	if err != nil {
+		return err
+	}
+	sourceFilename := ostrichFileInfo.Filename
+	if len(ostrichFileInfo.OldFilename) > 0 {
+		sourceFilename = ostrichFileInfo.OldFilename
+	}
+	sourceTexts, err := git.ShowFile(commit.ID+"^", sourceFilename)
+	if err != nil {
+		return err
+	}
+	sourceLines, err := DecodeLines(content, sourceTexts)
+	if err != nil {
+		return err
+	}
+	contents := content.Lines
+	lineMap, err := mapSourceLines(sourceLines, contents)
+	if err != nil {
+		return fmt.Errorf("%s.%s", ostrichFileInfo.Filename, err.Error())
+	}
+
+	firstLine := ""
+	if len(contents) > 0 {
+		firstLine = contents[0]
+	}
+	commentStyle, err := o.getCommentStyle(ostrichFileInfo.Filename, firstLine)
+	if err != nil {
+		return err
+	}
+	commentBase = commentStyle.Comment(commentBase)
+	// apply from last line because mapped line of upper hunk is not shifted
+	sort.Slice(
+		ostrichFileInfo.OstrichMergeInfos,
+		func(i, j int) bool {
+			return ostrichFileInfo.OstrichMergeInfos[i].sourceLine > ostrichFileInfo.OstrichMergeInfos[j].sourceLine
+		})
+	for _, mergeInfo := range ostrichFileInfo.OstrichMergeInfos {
+		mergeInfo, err = o.decodeOstrichMergeInfo(content, mergeInfo)
+		if err != nil {
+			return err
+		}
+		contents, mergeInfo.targetLine, err = replaceSourceLines(ostrichFileInfo.Filename, contents, lineMap, mergeInfo)
+		if err != nil {
+			return err
+		}
+		contents, err = o.applyOstrichMergeInfo(commentBase, commentStyle, contents, mergeInfo)
+		if err != nil {
+			return err
+		}
+	}
+	content.Lines = contents
+	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, content); err != nil {
+		return err
+	}
+	return git.Add(ostrichFileInfo.Filename)
+}
+
+// applyAccumulateMode is set new mode of written file.accumulate working tree is not read from source commit.
+func (o *Ostrich) applyAccumulateMode(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	switch ostrichFileInfo.InfoType {
+	case OstrichFileInfoTypeNewFile, OstrichFileInfoTypeModFile, OstrichFileInfoTypeRenameFile, OstrichFileInfoTypeCopyFile:
+	default:
+		return nil
+	}
+	if len(ostrichFileInfo.Mode) <= 0 {
+		return nil
+	}
+	return o.applyModeOstricFile(ostrichFileInfo, git)
+}
+
+// applySourceFile is apply source file of commit as-is.ostrich files are not same as source in accumulate mode.
+func (o *Ostrich) applySourceFile(commit Commit, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applySourceFile")
+	switch ostrichFileInfo.InfoType {
+	case OstrichFileInfoTypeDelFile:
+		return o.applyRemoveOstricFile(ostrichFileInfo, git)
+	case OstrichFileInfoTypeSubmodule:
+		return o.applySubmoduleOstricFile(ostrichFileInfo, git)
+	}
+	if err := git.CheckoutPath(commit.ID, ostrichFileInfo.Filename); err != nil {
+		return err
+	}
+	if ostrichFileInfo.InfoType != OstrichFileInfoTypeRenameFile {
+		return nil
+	}
+	return git.Rm(ostrichFileInfo.OldFilename)
+}
+
+// mapSourceLines is return index of ostrich line for each source line.
+// ostrich file is source file with inserted comment lines, so source lines are matched in order.
+func mapSourceLines(sourceLines []string, ostrichLines []string) ([]int, error) {
+	result := []int{}
+	index := 0
+	for i, line := range sourceLines {
+		for index < len(ostrichLines) && ostrichLines[index] != line {
+			index++
+		}
+		if index >= len(ostrichLines) {
+			return []int{}, fmt.Errorf("source line %d is not found in ostrich file.%s", i+1, line)
+		}
+		result = append(result, index)
+		index++
+	}
+	return result, nil
+}
+
+// replaceSourceLines is replace remove texts with after texts at mapped line and return line of after texts.
+// comment lines between remove texts are kept.
+func replaceSourceLines(filename string, contents []string, lineMap []int, mergeInfo OstrichMergeInfo) ([]string, int, error) {
+	start := mergeInfo.sourceLine - 1
+	end := start + len(mergeInfo.removeTexts)
+	if start < 0 || end > len(lineMap) {
+		return []string{}, 0, fmt.Errorf(
+			"source line %d and %d lines is out of %d lines in %s",
+			mergeInfo.sourceLine,
+			len(mergeInfo.removeTexts),
+			len(lineMap),
+			filename)
+	}
+	position := len(contents)
+	if start < len(lineMap) {
+		position = lineMap[start]
+	}
+	removed := map[int]bool{}
+	for i := start; i < end; i++ {
+		if contents[lineMap[i]] != mergeInfo.removeTexts[i-start] {
+			return []string{}, 0, &HunkConflictError{
+				Filename:   filename,
+				TargetLine: lineMap[i] + 1,
+				Expected:   mergeInfo.removeTexts,
+				Actual:     []string{contents[lineMap[i]]},
+			}
+		}
+		removed[lineMap[i]] = true
+	}
+
+	result := []string{}
+	result = append(result, contents[:position]...)
+	result = append(result, mergeInfo.afterTexts...)
+	for i := position; i < len(contents); i++ {
+		if !removed[i] {
+			result = append(result, contents[i])
+		}
+	}
+	return result, position + 1, nil
+}
@@ -1,6 +1,7 @@
 package ostrich
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"os/exec"
@@ -11,32 +12,81 @@ type CommandExecutorInterface interface {
 	ExecCommand(command string, args []string) ([]string, error)
 }
 
+// CommandExecutor is executing command in Dir.empty Dir is current directory.
 type CommandExecutor struct {
+	Dir string
 }
 
+// ExecCommand is return lines of stdout.stderr is not returned, so warnings of git are not parsed as output.
 func (c *CommandExecutor) ExecCommand(command string, args []string) ([]string, error) {
-	c.outputDebug(fmt.Sprintf("ExecCommand(): command: %s, args: %s", command, strings.Join(args, " ")))
+	c.outputDebug(fmt.Sprintf("ExecCommand(): dir: %s, command: %s, args: %s", c.Dir, command, strings.Join(args, " ")))
 	cmd := exec.Command(
 		command,
 		args...)
-	out, err := cmd.CombinedOutput()
+	cmd.Dir = c.Dir
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
 	if err != nil {
 		c.outputDebug("ExecCommand catch error -----")
-		c.outputDebug(fmt.Sprintf("stdout in error: %s", out))
+		c.outputDebug(fmt.Sprintf("stdout in error: %s", stdout.String()))
+		c.outputDebug(fmt.Sprintf("stderr in error: %s", stderr.String()))
 		c.outputDebug(fmt.Sprintf("error description: %s", err.Error()))
 		c.outputDebug(fmt.Sprintf("error: %#v", err))
 		c.outputDebug("-----------------------------")
-		return []string{}, 
-			fmt.Errorf(
-				"error: %s.command: %s, args: %s", 
-				err.Error(), 
-				command, 
-				strings.Join(args, " "))
+		return []string{}, &CommandError{
+			Command: command,
+			Args:    args,
+			Output:  strings.Split(stdout.String(), "\n"),
+			Stderr:  strings.Split(stderr.String(), "\n"),
+			Err:     err,
+		}
 	}
-	result := strings.Split(string(out), "\n")
+	if stderr.Len() > 0 {
+		c.outputDebug(fmt.Sprintf("stderr: %s", stderr.String()))
+	}
+	result := strings.Split(stdout.String(), "\n")
 	return result, nil
 }
 
+// CommandError is error of command with stdout and stderr.
+type CommandError struct {
+	Command string
+	Args    []string
+	Output  []string
+	Stderr  []string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf(
+		"error: %s.command: %s, args: %s",
+		e.Err.Error(),
+		e.Command,
+		strings.Join(e.Args, " "))
+}
+
+// OutputContains is return true when stdout or stderr has text.
+func (e *CommandError) OutputContains(text string) bool {
+	for _, line := range append(append([]string{}, e.Output...), e.Stderr...) {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *CommandExecutor) outputDebug(message string) {
 	log.Printf("[DEBUG]: %s", message)
 }
+
+// SkipCommandExecutor is executor for files without git.command is not executed.
+type SkipCommandExecutor struct {
+}
+
+func (c *SkipCommandExecutor) ExecCommand(command string, args []string) ([]string, error) {
+	log.Printf("[DEBUG]: skip command: %s, args: %s", command, strings.Join(args, " "))
+	return []string{}, nil
+}
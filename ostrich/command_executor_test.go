@@ -0,0 +1,29 @@
+package ostrich
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCommandExecutor(t *testing.T) {
+	executor := &CommandExecutor{}
+	t.Run("stderr is not output", func(t *testing.T) {
+		outs, err := executor.ExecCommand("sh", []string{"-c", "echo out; echo 'warning: refname is ambiguous' >&2"})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(outs) != 2 || outs[0] != "out" || outs[1] != "" {
+			t.Fatalf("invalid output %#v", outs)
+		}
+	})
+	t.Run("stderr is in error", func(t *testing.T) {
+		_, err := executor.ExecCommand("sh", []string{"-c", "echo out; echo ' ! [rejected]' >&2; exit 1"})
+		var commandErr *CommandError
+		if !errors.As(err, &commandErr) {
+			t.Fatalf("invalid return %#v", err)
+		}
+		if commandErr.Output[0] != "out" || commandErr.Stderr[0] != " ! [rejected]" || !commandErr.OutputContains("[rejected]") {
+			t.Fatalf("invalid error %#v", commandErr)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package ostrich
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CommentStyle is comment syntax of a language.
+// LinePrefix is used when exists, otherwise BlockStart and BlockEnd.
+type CommentStyle struct {
+	LinePrefix string
+	BlockStart string
+	BlockEnd   string
+}
+
+// Comment is return commented out text.own line ending of text is kept at last.ex) "a\r"
+func (c CommentStyle) Comment(text string) string {
+	lineEnding := ""
+	for _, ending := range []string{"\n", "\r"} {
+		if strings.HasSuffix(text, ending) {
+			text = strings.TrimSuffix(text, ending)
+			lineEnding = ending
+			break
+		}
+	}
+	if len(c.LinePrefix) > 0 || len(c.BlockEnd) <= 0 {
+		return c.LinePrefix + " " + text + lineEnding
+	}
+	return c.BlockStart + " " + c.escapeBlockEnd(text) + " " + c.BlockEnd + lineEnding
+}
+
+// escapeBlockEnd is breaking nested block end in text.ex) "-->" to "- ->"
+// xml comment can not have "--", so every "--" is broken.ex) "<!--" to "<!- -"
+func (c CommentStyle) escapeBlockEnd(text string) string {
+	if c.BlockStart == "<!--" {
+		for strings.Contains(text, "--") {
+			text = strings.Replace(text, "--", "- -", -1)
+		}
+		return text
+	}
+	closer := []rune(c.BlockEnd)
+	if len(closer) < 2 {
+		return text
+	}
+	escaped := string(closer[0]) + " " + string(closer[1:])
+	return strings.Replace(text, c.BlockEnd, escaped, -1)
+}
+
+// CommentStyleRegistry is mapping extension, filename and shebang to CommentStyle.
+type CommentStyleRegistry struct {
+	extensions map[string]CommentStyle
+	filenames  map[string]CommentStyle
+	shebangs   map[string]CommentStyle
+}
+
+// NewCommentStyleRegistry is return registry with built-in languages.
+func NewCommentStyleRegistry() *CommentStyleRegistry {
+	r := &CommentStyleRegistry{
+		extensions: map[string]CommentStyle{},
+		filenames:  map[string]CommentStyle{},
+		shebangs:   map[string]CommentStyle{},
+	}
+	for prefix, exts := range defaultExtensionPrefixes {
+		for _, ext := range exts {
+			r.AddExtension(ext, CommentStyle{LinePrefix: prefix})
+		}
+	}
+	for prefix, filenames := range defaultFilenamePrefixes {
+		for _, filename := range filenames {
+			r.AddFilename(filename, CommentStyle{LinePrefix: prefix})
+		}
+	}
+	for prefix, interpreters := range defaultShebangPrefixes {
+		for _, interpreter := range interpreters {
+			r.AddShebang(interpreter, CommentStyle{LinePrefix: prefix})
+		}
+	}
+	for _, block := range defaultExtensionBlocks {
+		for _, ext := range block.exts {
+			r.AddExtension(ext, CommentStyle{BlockStart: block.start, BlockEnd: block.end})
+		}
+	}
+	return r
+}
+
+// AddExtension is register comment style for file extension.ex) ".java"
+func (r *CommentStyleRegistry) AddExtension(ext string, style CommentStyle) {
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	r.extensions[strings.ToLower(ext)] = style
+}
+
+// AddFilename is register comment style for exact filename.ex) "Makefile"
+func (r *CommentStyleRegistry) AddFilename(filename string, style CommentStyle) {
+	r.filenames[filename] = style
+}
+
+// AddShebang is register comment style for shebang interpreter.ex) "python"
+func (r *CommentStyleRegistry) AddShebang(interpreter string, style CommentStyle) {
+	r.shebangs[interpreter] = style
+}
+
+// ErrNoCommentStyle is error when file type has no comment style.ex) .json, .txt
+var ErrNoCommentStyle = errors.New("no comment style")
+
+// Find is return comment style of file.
+// lookup order is filename, extension and shebang of first line.not registered file returns ErrNoCommentStyle.
+func (r *CommentStyleRegistry) Find(filename string, firstLine string) (CommentStyle, error) {
+	if style, ok := r.filenames[filepath.Base(filename)]; ok {
+		return style, nil
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if style, ok := r.extensions[ext]; ok {
+		return style, nil
+	}
+	interpreter := r.getShebangInterpreter(firstLine)
+	if len(interpreter) > 0 {
+		if style, ok := r.shebangs[interpreter]; ok {
+			return style, nil
+		}
+		// python3.8 -> python
+		if style, ok := r.shebangs[strings.TrimRight(interpreter, "0123456789.")]; ok {
+			return style, nil
+		}
+	}
+	return CommentStyle{}, fmt.Errorf("%w.%s", ErrNoCommentStyle, filename)
+}
+
+func (r *CommentStyleRegistry) getShebangInterpreter(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	// format: #!/usr/bin/env python3 or #!/bin/sh -e
+	terms := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(terms) <= 0 {
+		return ""
+	}
+	interpreter := filepath.Base(terms[0])
+	if interpreter == "env" {
+		for _, term := range terms[1:] {
+			if strings.HasPrefix(term, "-") {
+				continue
+			}
+			return filepath.Base(term)
+		}
+		return ""
+	}
+	return interpreter
+}
+
+var defaultExtensionPrefixes = map[string][]string{
+	"//": {
+		".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx",
+		".go", ".java", ".cs", ".kt", ".kts", ".scala", ".groovy", ".gradle",
+		".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx",
+		".swift", ".m", ".mm", ".rs", ".dart", ".php", ".proto",
+		".fs", ".fsx", ".scss", ".less", ".jenkinsfile",
+	},
+	"#": {
+		".py", ".rb", ".sh", ".bash", ".zsh", ".ksh", ".pl", ".pm", ".r",
+		".yml", ".yaml", ".toml", ".ps1", ".psm1", ".mk", ".cmake",
+		".properties", ".conf", ".tf", ".ex", ".exs", ".jl", ".nim",
+		".coffee", ".awk", ".dockerfile",
+	},
+	"--": {
+		".sql", ".lua", ".hs", ".elm", ".ada", ".adb", ".ads",
+	},
+	"'": {
+		".vb", ".vbs", ".bas", ".cls", ".frm",
+	},
+	"REM": {
+		".bat", ".cmd",
+	},
+	";": {
+		".ini", ".lisp", ".el", ".clj", ".scm", ".asm",
+	},
+	"%": {
+		".erl", ".hrl", ".tex",
+	},
+	"!": {
+		".f90", ".f95", ".f03",
+	},
+	"*>": {
+		".cob", ".cbl", ".cpy",
+	},
+}
+
+// for languages without line comment
+var defaultExtensionBlocks = []struct {
+	start string
+	end   string
+	exts  []string
+}{
+	{
+		start: "<!--",
+		end:   "-->",
+		exts: []string{
+			".html", ".htm", ".xhtml", ".xml", ".xsd", ".xsl", ".xslt",
+			".svg", ".xaml", ".csproj", ".vbproj", ".resx", ".plist", ".md",
+		},
+	},
+	{
+		start: "/*",
+		end:   "*/",
+		exts:  []string{".css"},
+	},
+	{
+		start: "<%--",
+		end:   "--%>",
+		exts:  []string{".jsp", ".jspf", ".tag"},
+	},
+}
+
+var defaultFilenamePrefixes = map[string][]string{
+	"#": {
+		"Makefile", "makefile", "GNUmakefile", "Dockerfile", "Containerfile",
+		"Rakefile", "Gemfile", "Vagrantfile", "CMakeLists.txt",
+		".gitignore", ".gitattributes", ".dockerignore", ".bashrc", ".profile",
+	},
+	"//": {
+		"Jenkinsfile",
+	},
+}
+
+var defaultShebangPrefixes = map[string][]string{
+	"#": {
+		"sh", "bash", "zsh", "ksh", "dash", "ash",
+		"python", "ruby", "perl", "Rscript", "awk",
+	},
+	"//": {
+		"node", "deno",
+	},
+	"--": {
+		"lua",
+	},
+}
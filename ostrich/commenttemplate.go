@@ -0,0 +1,150 @@
+package ostrich
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// format: {NAME} or {DATE:2006-01-02}
+var commentTemplatePlaceholder = regexp.MustCompile(`\{([A-Z_]+)(?::([^}]*))?\}`)
+
+// placeholders replaced in generateOstrichComment
+var commentTemplateLatePlaceholders = []string{
+	"OSTRICH_TYPE",
+	"RANGE_TAG",
+}
+
+var commentTemplatePlaceholders = []string{
+	"DATE",
+	"AUTHOR",
+	"AUTHOR_NAME",
+	"AUTHOR_EMAIL",
+	"COMMITTER",
+	"COMMITTER_EMAIL",
+	"COMMITTER_DATE",
+	"MESSAGE",
+	"SUBJECT",
+	"COMMIT_ID",
+	"SHORT_COMMIT_ID",
+	"BRANCH",
+	"FILE",
+	"OLD_FILE",
+	"TICKET",
+}
+
+// CommentTemplateValues is values of history comment placeholders.
+// AuthorName is display name of author.ex) employee code in author map
+type CommentTemplateValues struct {
+	Commit      Commit
+	AuthorName  string
+	CommitID    string
+	Branch      string
+	Filename    string
+	OldFilename string
+	Ticket      string
+}
+
+// RenderCommentTemplate is replace placeholders except {OSTRICH_TYPE} and {RANGE_TAG}.
+func RenderCommentTemplate(template string, dateFormat string, values CommentTemplateValues) string {
+	return commentTemplatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		terms := commentTemplatePlaceholder.FindStringSubmatch(placeholder)
+		name := terms[1]
+		switch name {
+		case "DATE":
+			if len(terms[2]) > 0 {
+				return values.Commit.CommitDate.Format(terms[2])
+			}
+			return values.Commit.CommitDate.Format(dateFormat)
+		case "COMMITTER_DATE":
+			if len(terms[2]) > 0 {
+				return values.Commit.CommitterDate.Format(terms[2])
+			}
+			return values.Commit.CommitterDate.Format(dateFormat)
+		case "AUTHOR":
+			if len(values.AuthorName) > 0 {
+				return values.AuthorName
+			}
+			return values.Commit.Author
+		case "AUTHOR_NAME":
+			return values.Commit.Author
+		case "AUTHOR_EMAIL":
+			return values.Commit.AuthorEmail
+		case "COMMITTER":
+			return values.Commit.Committer
+		case "COMMITTER_EMAIL":
+			return values.Commit.CommitterEmail
+		case "MESSAGE":
+			return values.Commit.Message
+		case "SUBJECT":
+			return values.Commit.Subject
+		case "COMMIT_ID":
+			return values.CommitID
+		case "SHORT_COMMIT_ID":
+			if len(values.CommitID) > 7 {
+				return values.CommitID[:7]
+			}
+			return values.CommitID
+		case "BRANCH":
+			return values.Branch
+		case "FILE":
+			return strings.TrimPrefix(values.Filename, "./")
+		case "OLD_FILE":
+			return strings.TrimPrefix(values.OldFilename, "./")
+		case "TICKET":
+			return values.Ticket
+		}
+		return placeholder
+	})
+}
+
+// ValidateCommentTemplate is return error when template has unknown placeholder or no {RANGE_TAG}.
+func ValidateCommentTemplate(template string) error {
+	if err := ValidateCommentPlaceholders(template); err != nil {
+		return err
+	}
+	if !strings.Contains(template, "{RANGE_TAG}") {
+		return fmt.Errorf("comment template must have {RANGE_TAG}.%s", template)
+	}
+	return nil
+}
+
+// ValidateCommentPlaceholders is return error when template has unknown placeholder.
+func ValidateCommentPlaceholders(template string) error {
+	known := []string{}
+	known = append(known, commentTemplatePlaceholders...)
+	known = append(known, commentTemplateLatePlaceholders...)
+	for _, terms := range commentTemplatePlaceholder.FindAllStringSubmatch(template, -1) {
+		found := false
+		for _, name := range known {
+			if terms[1] == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid comment template placeholder %s", terms[0])
+		}
+	}
+	return nil
+}
+
+// ExtractTicket is return first ticket id in commit message.
+func ExtractTicket(pattern string, message string) (string, error) {
+	if len(pattern) <= 0 {
+		return "", nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	terms := re.FindStringSubmatch(message)
+	if len(terms) <= 0 {
+		return "", nil
+	}
+	// use first group when pattern has group.ex) refs #([0-9]+)
+	if len(terms) > 1 {
+		return terms[1], nil
+	}
+	return terms[0], nil
+}
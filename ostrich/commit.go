@@ -1,12 +1,70 @@
 package ostrich
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
+// Commit is source commit.Message is one line joined message for comment, and RawMessage is message as-is for ostrich commit.
 type Commit struct {
+	ID               string
 	Message          string
+	RawMessage       string
+	Subject          string
 	Author           string
-	CommitDate       time.Time
+	AuthorEmail      string
+	CommitDate       time.Time // author date
+	Committer        string
+	CommitterEmail   string
+	CommitterDate    time.Time
 	OstrichFileInfos []OstrichFileInfo
 }
+
+// CommitIdentity is author or committer of ostrich commit.
+type CommitIdentity struct {
+	Name  string
+	Email string
+	Date  time.Time
+}
+
+func (c CommitIdentity) String() string {
+	return fmt.Sprintf("%s <%s>", c.Name, c.Email)
+}
+
+// ValidateCommitter is return error when committer has only name or only email.empty is git config.
+func ValidateCommitter(committer CommitIdentity) error {
+	if (len(committer.Name) > 0) != (len(committer.Email) > 0) {
+		return fmt.Errorf("committer name and email must be used together.name: %s, email: %s", committer.Name, committer.Email)
+	}
+	return nil
+}
+
+// joinMessageLines is return message joined not empty lines and subject of first line.
+func joinMessageLines(messageLines []string) (string, string) {
+	message := ""
+	subject := ""
+	for _, text := range messageLines {
+		buff := strings.Trim(text, " ")
+		if len(buff) <= 0 {
+			continue
+		}
+		message = message + ", " + buff
+		if len(subject) <= 0 {
+			subject = buff
+		}
+	}
+	if len(message) > 0 {
+		message = strings.Replace(message, ", ", "", 1)
+	}
+	return message, subject
+}
+
+// parseIdentity is return name and email of "Name <email>".
+func parseIdentity(text string) (string, string) {
+	index := strings.LastIndex(text, " <")
+	if index < 0 || !strings.HasSuffix(text, ">") {
+		return strings.TrimSpace(text), ""
+	}
+	return text[:index], text[index+2 : len(text)-1]
+}
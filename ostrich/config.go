@@ -0,0 +1,264 @@
+package ostrich
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFilename is project config file in target repository.
+const ConfigFilename = ".ostrich.yml"
+
+// Config is project level ostrich setting.
+type Config struct {
+	Comment   CommentConfig    `yaml:"comment"`
+	Labels    LabelConfig      `yaml:"labels"`
+	Languages []LanguageConfig `yaml:"languages"`
+	Include   []string         `yaml:"include"`
+	Exclude   []string         `yaml:"exclude"`
+	Encodings []EncodingConfig `yaml:"encodings"`
+	Apply     ApplyConfig      `yaml:"apply"`
+	Branch    BranchConfig     `yaml:"branch"`
+
+	// author map file in target repository.format is "email: display name"
+	AuthorMapFile string `yaml:"authorMapFile"`
+}
+
+// CommentConfig is history comment format.
+// template placeholders are {DATE}, {DATE:layout}, {AUTHOR}, {MESSAGE}, {SUBJECT},
+// {COMMIT_ID}, {SHORT_COMMIT_ID}, {BRANCH}, {FILE}, {TICKET}, {OSTRICH_TYPE} and {RANGE_TAG}.
+// date format is go layout.ex) 2006/01/02
+// ticket pattern is regexp for {TICKET}.first group is used when exists.empty is no ticket.
+// rename header is comment at first line of renamed or copied file.{OLD_FILE} is source file.
+type CommentConfig struct {
+	Template      string `yaml:"template"`
+	DateFormat    string `yaml:"dateFormat"`
+	TicketPattern string `yaml:"ticketPattern"`
+	RenameHeader  string `yaml:"renameHeader"`
+}
+
+// LabelConfig is text of {OSTRICH_TYPE} and {RANGE_TAG}.
+type LabelConfig struct {
+	Add    string `yaml:"add"`
+	Mod    string `yaml:"mod"`
+	Del    string `yaml:"del"`
+	Rename string `yaml:"rename"`
+	Copy   string `yaml:"copy"`
+	Start  string `yaml:"start"`
+	End    string `yaml:"end"`
+}
+
+// LanguageConfig is additional comment style mapping.
+type LanguageConfig struct {
+	Extensions []string `yaml:"extensions"`
+	Filenames  []string `yaml:"filenames"`
+	Shebangs   []string `yaml:"shebangs"`
+	LinePrefix string   `yaml:"linePrefix"`
+	BlockStart string   `yaml:"blockStart"`
+	BlockEnd   string   `yaml:"blockEnd"`
+}
+
+// ApplyConfig is matching hunk to file content.
+// search window is max line offset from hunk line.
+// fuzz is max ignored context lines like patch --fuzz.
+type ApplyConfig struct {
+	SearchWindow int `yaml:"searchWindow"`
+	Fuzz         int `yaml:"fuzz"`
+}
+
+// new ostrich branch policy of BranchConfig
+const (
+	NewBranchSource = "source"
+	NewBranchOrphan = "orphan"
+)
+
+// BranchConfig is ostrich branch setting.
+// new is policy when ostrich branch not exists.source is based on from branch, orphan is no history.
+type BranchConfig struct {
+	New string `yaml:"new"`
+}
+
+// EncodingConfig is character encoding of files matched paths.
+// encoding is WHATWG label.ex) shift_jis, euc-jp, utf-8 or auto
+type EncodingConfig struct {
+	Paths    []string `yaml:"paths"`
+	Encoding string   `yaml:"encoding"`
+}
+
+// NewDefaultConfig is return config used when project has no config file.
+func NewDefaultConfig() Config {
+	return Config{
+		Comment: CommentConfig{
+			Template:   "{DATE} {OSTRICH_TYPE} {AUTHOR} {RANGE_TAG}",
+			DateFormat: "2006/01/02",
+		},
+		Labels: LabelConfig{
+			Add:    "ADD",
+			Mod:    "MOD",
+			Del:    "DEL",
+			Rename: "RENAME",
+			Copy:   "COPY",
+			Start:  "START",
+			End:    "END",
+		},
+		Languages: []LanguageConfig{},
+		Include:   []string{},
+		Exclude:   []string{},
+		Encodings: []EncodingConfig{},
+		Apply: ApplyConfig{
+			SearchWindow: 100,
+			Fuzz:         2,
+		},
+		Branch: BranchConfig{
+			New: NewBranchSource,
+		},
+		AuthorMapFile: ".ostrich-authors.yml",
+	}
+}
+
+// ParseConfig is return config overwritten default by yaml text.
+func ParseConfig(text string) (Config, error) {
+	config := NewDefaultConfig()
+	if err := yaml.Unmarshal([]byte(text), &config); err != nil {
+		return Config{}, err
+	}
+	if err := ValidateCommentTemplate(config.Comment.Template); err != nil {
+		return Config{}, err
+	}
+	if err := ValidateCommentPlaceholders(config.Comment.RenameHeader); err != nil {
+		return Config{}, err
+	}
+	if _, err := regexp.Compile(config.Comment.TicketPattern); err != nil {
+		return Config{}, fmt.Errorf("invalid ticket pattern %s.%s", config.Comment.TicketPattern, err.Error())
+	}
+	if config.Apply.SearchWindow < 0 || config.Apply.Fuzz < 0 {
+		return Config{}, fmt.Errorf("invalid apply config.search window %d, fuzz %d", config.Apply.SearchWindow, config.Apply.Fuzz)
+	}
+	if config.Branch.New != NewBranchSource && config.Branch.New != NewBranchOrphan {
+		return Config{}, fmt.Errorf("invalid new branch policy %s.source or orphan", config.Branch.New)
+	}
+	for _, language := range config.Languages {
+		if err := ValidateLanguage(language); err != nil {
+			return Config{}, err
+		}
+	}
+	patterns := append(append([]string{}, config.Include...), config.Exclude...)
+	for _, encoding := range config.Encodings {
+		if err := ValidateEncoding(encoding.Encoding); err != nil {
+			return Config{}, err
+		}
+		patterns = append(patterns, encoding.Paths...)
+	}
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return Config{}, fmt.Errorf("invalid path pattern %s.%s", pattern, err.Error())
+		}
+	}
+	return config, nil
+}
+
+// ValidateLanguage is return error when language has no file to match or no comment.
+// comment is line prefix, or both block start and block end.
+func ValidateLanguage(language LanguageConfig) error {
+	if len(language.Extensions) <= 0 && len(language.Filenames) <= 0 && len(language.Shebangs) <= 0 {
+		return fmt.Errorf("language has no extensions, filenames or shebangs.%#v", language)
+	}
+	if len(language.LinePrefix) <= 0 && (len(language.BlockStart) <= 0 || len(language.BlockEnd) <= 0) {
+		return fmt.Errorf("language has no linePrefix or blockStart and blockEnd.%#v", language)
+	}
+	return nil
+}
+
+// ParseAuthorMap is return author email to display name map.
+func ParseAuthorMap(text string) (map[string]string, error) {
+	authorMap := map[string]string{}
+	if err := yaml.Unmarshal([]byte(text), &authorMap); err != nil {
+		return map[string]string{}, err
+	}
+	result := map[string]string{}
+	for email, name := range authorMap {
+		result[strings.ToLower(email)] = name
+	}
+	return result, nil
+}
+
+// RegisterLanguages is add languages to registry.
+func (c *Config) RegisterLanguages(registry *CommentStyleRegistry) {
+	for _, language := range c.Languages {
+		style := CommentStyle{
+			LinePrefix: language.LinePrefix,
+			BlockStart: language.BlockStart,
+			BlockEnd:   language.BlockEnd,
+		}
+		for _, ext := range language.Extensions {
+			registry.AddExtension(ext, style)
+		}
+		for _, filename := range language.Filenames {
+			registry.AddFilename(filename, style)
+		}
+		for _, interpreter := range language.Shebangs {
+			registry.AddShebang(interpreter, style)
+		}
+	}
+}
+
+// IsTarget is return true when file is matched include and not matched exclude.
+func (c *Config) IsTarget(filename string) bool {
+	filename = strings.TrimPrefix(filepath.ToSlash(filename), "./")
+	if len(c.Include) > 0 {
+		included := false
+		for _, pattern := range c.Include {
+			if matchPathGlob(pattern, filename) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, pattern := range c.Exclude {
+		if matchPathGlob(pattern, filename) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPathGlob is filepath.Match with "**" for any directories.
+// pattern without "/" is matched to base name.ex) *.md
+func matchPathGlob(pattern string, filename string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if !strings.Contains(pattern, "/") {
+		matched, _ := filepath.Match(pattern, filepath.Base(filename))
+		return matched
+	}
+	return matchPathTerms(
+		strings.Split(strings.TrimPrefix(pattern, "/"), "/"),
+		strings.Split(filename, "/"))
+}
+
+func matchPathTerms(patterns []string, terms []string) bool {
+	if len(patterns) <= 0 {
+		return len(terms) <= 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(terms); i++ {
+			if matchPathTerms(patterns[1:], terms[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(terms) <= 0 {
+		return false
+	}
+	matched, err := filepath.Match(patterns[0], terms[0])
+	if err != nil || !matched {
+		return false
+	}
+	return matchPathTerms(patterns[1:], terms[1:])
+}
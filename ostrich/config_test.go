@@ -0,0 +1,152 @@
+package ostrich
+
+import (
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Run("empty text", func(t *testing.T) {
+		config, err := ParseConfig("")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expect := NewDefaultConfig()
+		if config.Comment.Template != expect.Comment.Template {
+			t.Fatalf("invalid template %s", config.Comment.Template)
+		}
+		if config.Labels.Mod != "MOD" {
+			t.Fatalf("invalid mod label %s", config.Labels.Mod)
+		}
+	})
+	t.Run("overwrite default", func(t *testing.T) {
+		text := `
+comment:
+  template: "[{DATE}][{OSTRICH_TYPE}][{AUTHOR}] {RANGE_TAG}"
+  dateFormat: "2006-01-02"
+labels:
+  mod: CHG
+languages:
+  - extensions: [".pks", ".pkb"]
+    linePrefix: "--"
+exclude:
+  - "vendor/**"
+`
+		config, err := ParseConfig(text)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if config.Comment.Template != "[{DATE}][{OSTRICH_TYPE}][{AUTHOR}] {RANGE_TAG}" {
+			t.Fatalf("invalid template %s", config.Comment.Template)
+		}
+		if config.Comment.DateFormat != "2006-01-02" {
+			t.Fatalf("invalid date format %s", config.Comment.DateFormat)
+		}
+		if config.Labels.Mod != "CHG" {
+			t.Fatalf("invalid mod label %s", config.Labels.Mod)
+		}
+		if config.Labels.Add != "ADD" {
+			t.Fatalf("invalid add label %s", config.Labels.Add)
+		}
+		registry := NewCommentStyleRegistry()
+		config.RegisterLanguages(registry)
+		style, err := registry.Find("./db/package.pkb", "")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if style.LinePrefix != "--" {
+			t.Fatalf("invalid line prefix %s", style.LinePrefix)
+		}
+	})
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := ParseConfig("labels: [")
+		if err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("encodings", func(t *testing.T) {
+		text := `
+encodings:
+  - paths: ["legacy/**", "*.bas"]
+    encoding: shift_jis
+  - paths: ["batch/**"]
+    encoding: auto
+`
+		config, err := ParseConfig(text)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(config.Encodings) != 2 || config.Encodings[0].Encoding != "shift_jis" {
+			t.Fatalf("invalid encodings %#v", config.Encodings)
+		}
+	})
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := ParseConfig("encodings:\n  - paths: [\"*.c\"]\n    encoding: sjis-x\n")
+		if err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("invalid languages", func(t *testing.T) {
+		texts := []string{
+			"languages:\n  - extensions: [\".vue\"]\n",
+			"languages:\n  - extensions: [\".vue\"]\n    blockStart: \"<!--\"\n",
+			"languages:\n  - blockStart: \"<!--\"\n    blockEnd: \"-->\"\n",
+		}
+		for _, text := range texts {
+			if _, err := ParseConfig(text); err == nil {
+				t.Fatalf("not return error %s", text)
+			}
+		}
+		if _, err := ParseConfig("languages:\n  - filenames: [\"Jenkinsfile\"]\n    linePrefix: \"//\"\n"); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+	})
+	t.Run("invalid path pattern", func(t *testing.T) {
+		texts := []string{
+			"include:\n  - \"src/[a-\"\n",
+			"exclude:\n  - \"*.[md\"\n",
+			"encodings:\n  - paths: [\"legacy/[\"]\n    encoding: shift_jis\n",
+		}
+		for _, text := range texts {
+			if _, err := ParseConfig(text); err == nil {
+				t.Fatalf("not return error %s", text)
+			}
+		}
+	})
+	t.Run("new branch policy", func(t *testing.T) {
+		config, err := ParseConfig("branch:\n  new: orphan\n")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if config.Branch.New != NewBranchOrphan {
+			t.Fatalf("invalid new branch policy %s", config.Branch.New)
+		}
+		if _, err := ParseConfig("branch:\n  new: empty\n"); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestConfigIsTarget(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Include = []string{"src/**"}
+	config.Exclude = []string{"src/generated/**", "*.md"}
+	filenames := []string{
+		"./src/main.go",
+		"./src/app/handler.go",
+		"./src/generated/model.go",
+		"./src/README.md",
+		"./tools/build.sh",
+	}
+	expects := []bool{
+		true,
+		true,
+		false,
+		false,
+		false,
+	}
+	for i, filename := range filenames {
+		if config.IsTarget(filename) != expects[i] {
+			t.Fatalf("invalid result %s.expect %t", filename, expects[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package ostrich
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// EncodingAuto is detecting encoding from file content.
+const EncodingAuto = "auto"
+
+const utf8BOM = "\xef\xbb\xbf"
+
+// detected when file is not utf-8
+var detectEncodingCandidates = []string{
+	"shift_jis",
+	"euc-jp",
+}
+
+// getEncoding is return encoding of WHATWG label.ex) shift_jis, euc-jp, utf-8
+func getEncoding(name string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %s", name)
+	}
+	return enc, nil
+}
+
+// ValidateEncoding is return error when name is not auto or known encoding.
+func ValidateEncoding(name string) error {
+	if name == EncodingAuto {
+		return nil
+	}
+	_, err := getEncoding(name)
+	return err
+}
+
+// DetectEncoding is return encoding name guessed from content.
+// empty is utf-8 or unknown, then content is used as-is.
+func DetectEncoding(content []byte) string {
+	if utf8.Valid(content) {
+		return ""
+	}
+	result := ""
+	resultKatakana := -1
+	for _, name := range detectEncodingCandidates {
+		enc, err := getEncoding(name)
+		if err != nil {
+			continue
+		}
+		decoded, err := enc.NewDecoder().Bytes(content)
+		if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		// euc-jp decoded as shift_jis is many halfwidth katakana
+		katakana := countHalfwidthKatakana(decoded)
+		if resultKatakana < 0 || katakana < resultKatakana {
+			result = name
+			resultKatakana = katakana
+		}
+	}
+	return result
+}
+
+func countHalfwidthKatakana(text []byte) int {
+	count := 0
+	for _, r := range string(text) {
+		if r >= '｡' && r <= 'ﾟ' {
+			count++
+		}
+	}
+	return count
+}
+
+// decodeText is return utf-8 text of content.empty name is as-is.
+func decodeText(name string, content []byte) (string, error) {
+	if len(name) <= 0 {
+		return string(content), nil
+	}
+	enc, err := getEncoding(name)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// encodeText is return content of utf-8 text.empty name is as-is.
+func encodeText(name string, text []byte) ([]byte, error) {
+	if len(name) <= 0 {
+		return text, nil
+	}
+	enc, err := getEncoding(name)
+	if err != nil {
+		return []byte{}, err
+	}
+	return enc.NewEncoder().Bytes(text)
+}
+
+// DecodeLines is return utf-8 lines of raw lines in git output.
+// BOM and "\r" are removed in same format as lines of content.
+func DecodeLines(content FileContent, lines []string) ([]string, error) {
+	result := []string{}
+	for _, line := range lines {
+		if content.BOM {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
+		lineEnding := ""
+		if content.LineEnding == LineEndingCRLF {
+			if strings.HasSuffix(line, "\r") {
+				line = strings.TrimSuffix(line, "\r")
+			} else if content.MixedLineEnding {
+				lineEnding = LineEndingLF
+			}
+		}
+		decoded, err := decodeText(content.Encoding, []byte(line))
+		if err != nil {
+			return []string{}, err
+		}
+		result = append(result, decoded+lineEnding)
+	}
+	return result, nil
+}
@@ -2,37 +2,101 @@ package ostrich
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
-	"unsafe"
 )
 
 type FileAccesserInterface interface {
-	ReadAll(filepath string) ([]string, error)
-	WriteAll(filepath string, contents []string) error
+	ReadAll(filepath string) (FileContent, error)
+	WriteAll(filepath string, content FileContent) error
 	RemoveFile(filepath string) error
+	SetEncodings(encodings []EncodingConfig)
+	SetDir(dir string)
 }
 
+// line ending of FileContent
+const (
+	LineEndingLF   = "\n"
+	LineEndingCRLF = "\r\n"
+)
+
+// FileContent is lines of file and format to restore when writing.
+// Lines has no empty line for last "\n" and no "\r" of LineEndingCRLF.
+// Lines are utf-8 and Encoding is original encoding.empty Encoding is written as-is.
+// empty LineEnding is LineEndingLF.
+// line ending of other lines is kept in line.ex) "a\r" in LineEndingLF, "a\n" in LineEndingCRLF with MixedLineEnding
+type FileContent struct {
+	Lines           []string
+	NoNewlineAtEOF  bool
+	Encoding        string
+	BOM             bool
+	LineEnding      string
+	MixedLineEnding bool // LineEndingCRLF content has lines with bare "\n"
+}
+
+// FileAccesser is file access with encoding.
+// Encodings is encoding of paths, and file not matched is detected from content.
+// relative path is in Dir.empty Dir is current directory.
 type FileAccesser struct {
+	Encodings []EncodingConfig
+	Dir       string
 }
 
 // ReadAll is return content splited '\n' string
-func (f *FileAccesser) ReadAll(filepath string) ([]string, error) {
-	contents, err := ioutil.ReadFile(filepath)
+func (f *FileAccesser) ReadAll(filepath string) (FileContent, error) {
+	contents, err := ioutil.ReadFile(f.path(filepath))
 	if err != nil {
-		return []string{}, err
+		return FileContent{}, err
+	}
+	if len(contents) <= 0 {
+		return FileContent{Lines: []string{}}, nil
+	}
+	bom := bytes.HasPrefix(contents, []byte(utf8BOM))
+	if bom {
+		contents = contents[len(utf8BOM):]
+	}
+	encodingName := f.getEncodingName(filepath, contents)
+	contentStr, err := decodeText(encodingName, contents)
+	if err != nil {
+		return FileContent{}, fmt.Errorf("can not decode %s from %s.%s", filepath, encodingName, err.Error())
+	}
+	content := FileContent{
+		Encoding: encodingName,
+		BOM:      bom,
 	}
-	contentStr := *(*string)(unsafe.Pointer(&contents))
 	splittedContent := strings.Split(contentStr, "\n")
-	return splittedContent, nil
+	lastIndex := len(splittedContent) - 1
+	if len(splittedContent[lastIndex]) > 0 {
+		content.Lines = splittedContent
+		content.NoNewlineAtEOF = true
+	} else {
+		content.Lines = splittedContent[:lastIndex]
+	}
+	content.LineEnding = f.detectLineEnding(splittedContent[:lastIndex])
+	if content.LineEnding == LineEndingCRLF {
+		content.Lines, content.MixedLineEnding = markLineEndings(content.Lines)
+	}
+	return content, nil
 
 }
 
 // WriteAll is write file
-func (f *FileAccesser) WriteAll(filepath string, contents []string) error {
-	byteContent := f.strings2Bytes(contents)
-	err := ioutil.WriteFile(filepath, byteContent, 0644)
+func (f *FileAccesser) WriteAll(filepath string, content FileContent) error {
+	lineEnding := content.LineEnding
+	if len(lineEnding) <= 0 {
+		lineEnding = LineEndingLF
+	}
+	byteContent, err := encodeText(content.Encoding, f.strings2Bytes(content.Lines, lineEnding, content.NoNewlineAtEOF))
+	if err != nil {
+		return fmt.Errorf("can not encode %s to %s.%s", filepath, content.Encoding, err.Error())
+	}
+	if content.BOM {
+		byteContent = append([]byte(utf8BOM), byteContent...)
+	}
+	err = ioutil.WriteFile(f.path(filepath), byteContent, 0644)
 	if err != nil {
 		return err
 	}
@@ -42,15 +106,97 @@ func (f *FileAccesser) WriteAll(filepath string, contents []string) error {
 
 // RemoveFile is removing file.
 func (f *FileAccesser) RemoveFile(filepath string) error {
-	return os.Remove(filepath)
+	return os.Remove(f.path(filepath))
+}
+
+// SetEncodings is set encoding of paths in project config.
+func (f *FileAccesser) SetEncodings(encodings []EncodingConfig) {
+	f.Encodings = encodings
+}
+
+// SetDir is set directory of relative path.
+func (f *FileAccesser) SetDir(dir string) {
+	f.Dir = dir
+}
+
+// path is return path in Dir.
+func (f *FileAccesser) path(path string) string {
+	if len(f.Dir) <= 0 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(f.Dir, path)
+}
+
+// getEncodingName is return first matched encoding, otherwise detected encoding.
+func (f *FileAccesser) getEncodingName(path string, contents []byte) string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for _, encoding := range f.Encodings {
+		for _, pattern := range encoding.Paths {
+			if !matchPathGlob(pattern, path) {
+				continue
+			}
+			if encoding.Encoding == EncodingAuto {
+				return DetectEncoding(contents)
+			}
+			return encoding.Encoding
+		}
+	}
+	return DetectEncoding(contents)
+}
+
+// detectLineEnding is return LineEndingCRLF when most of lines end with "\r".
+func (f *FileAccesser) detectLineEnding(lines []string) string {
+	crlfCount := 0
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			crlfCount++
+		}
+	}
+	if crlfCount > 0 && crlfCount*2 >= len(lines) {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// TrimCarriageReturns is return lines without own line ending of last "\r" or "\n".
+func TrimCarriageReturns(lines []string) []string {
+	result := []string{}
+	for _, line := range lines {
+		result = append(result, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+	}
+	return result
+}
+
+// markLineEndings is return lines of LineEndingCRLF content without "\r", and lines with bare "\n" end with "\n".
+// so lines with other line ending are not rewritten.second result is true when "\n" line exists.
+func markLineEndings(lines []string) ([]string, bool) {
+	result := []string{}
+	mixed := false
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\r") {
+			result = append(result, strings.TrimSuffix(line, "\r"))
+			continue
+		}
+		result = append(result, line+LineEndingLF)
+		mixed = true
+	}
+	return result, mixed
 }
 
-func (f *FileAccesser) strings2Bytes(texts []string) []byte {
+// strings2Bytes is join lines with recode.line ending with "\n" is written with own line ending.
+func (f *FileAccesser) strings2Bytes(texts []string, recode string, noNewlineAtEOF bool) []byte {
 	content := bytes.NewBuffer(make([]byte, 0, 1024)) //1K bytes capacity
-	recode := "\n"
-	for _, line := range texts {
+	for i, line := range texts {
+		lineEnding := recode
+		if strings.HasSuffix(line, LineEndingLF) {
+			line = strings.TrimSuffix(line, LineEndingLF)
+			lineEnding = LineEndingLF
+		}
 		content.WriteString(line)
-		content.WriteString(recode)
+		if noNewlineAtEOF && i == len(texts)-1 {
+			break
+		}
+		content.WriteString(lineEnding)
 	}
 	return content.Bytes()
 }
@@ -0,0 +1,217 @@
+package ostrich
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileAccesserReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileAccessor := &FileAccesser{}
+	tests := []struct {
+		name           string
+		text           string
+		lineLength     int
+		noNewlineAtEOF bool
+	}{
+		{name: "newline at end of file", text: "package main\n\nfunc main() {}\n", lineLength: 3},
+		{name: "no newline at end of file", text: "package main\n\nfunc main() {}", lineLength: 3, noNewlineAtEOF: true},
+		{name: "empty file", text: "", lineLength: 0},
+		{name: "newline only", text: "\n", lineLength: 1},
+		{name: "crlf", text: "package main\r\n\r\nfunc main() {}\r\n", lineLength: 3},
+		{name: "crlf and no newline at end of file", text: "package main\r\n\r\nfunc main() {}", lineLength: 3, noNewlineAtEOF: true},
+		{name: "mostly crlf", text: "package main\r\n\nfunc main() {}\r\n", lineLength: 3},
+		{name: "mostly lf", text: "package main\n\r\nfunc main() {}\n", lineLength: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, "main.go")
+			if err := ioutil.WriteFile(path, []byte(test.text), 0644); err != nil {
+				t.Fatalf("can not write test file.%#v", err)
+			}
+			content, err := fileAccessor.ReadAll(path)
+			if err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			if len(content.Lines) != test.lineLength {
+				t.Fatalf("invalid line length.expect %d, result %d", test.lineLength, len(content.Lines))
+			}
+			if content.NoNewlineAtEOF != test.noNewlineAtEOF {
+				t.Fatalf("invalid no newline at end of file.expect %t, result %t", test.noNewlineAtEOF, content.NoNewlineAtEOF)
+			}
+			for i, line := range content.Lines {
+				if content.LineEnding == LineEndingCRLF && strings.HasSuffix(line, "\r") {
+					t.Fatalf("line %d has carriage return", i)
+				}
+			}
+
+			// round trip is not changed
+			if err := fileAccessor.WriteAll(path, content); err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("can not read test file.%#v", err)
+			}
+			if string(b) != test.text {
+				t.Fatalf("invalid round trip.expect %q, result %q", test.text, string(b))
+			}
+		})
+	}
+}
+
+func TestFileAccesserEncoding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name      string
+		encodings []EncodingConfig
+		text      string
+		encoding  string
+		bom       bool
+		firstLine string
+	}{
+		// "// 山田" in each encoding
+		{name: "utf-8", text: "// \xe5\xb1\xb1\xe7\x94\xb0\n", encoding: "", firstLine: "// 山田"},
+		{name: "utf-8 with bom", text: "\xef\xbb\xbf// \xe5\xb1\xb1\xe7\x94\xb0\n", encoding: "", bom: true, firstLine: "// 山田"},
+		{name: "detect shift_jis", text: "// \x8eR\x93c\n", encoding: "shift_jis", firstLine: "// 山田"},
+		{name: "detect euc-jp", text: "// \xbb\xb3\xc5\xc4\n", encoding: "euc-jp", firstLine: "// 山田"},
+		{
+			name:      "configured encoding",
+			encodings: []EncodingConfig{{Paths: []string{"*.bas"}, Encoding: "euc-jp"}},
+			text:      "// \xbb\xb3\xc5\xc4\n",
+			encoding:  "euc-jp",
+			firstLine: "// 山田",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileAccessor := &FileAccesser{}
+			fileAccessor.SetEncodings(test.encodings)
+			path := filepath.Join(dir, "main.bas")
+			if err := ioutil.WriteFile(path, []byte(test.text), 0644); err != nil {
+				t.Fatalf("can not write test file.%#v", err)
+			}
+			content, err := fileAccessor.ReadAll(path)
+			if err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			if content.Encoding != test.encoding {
+				t.Fatalf("invalid encoding.expect %s, result %s", test.encoding, content.Encoding)
+			}
+			if content.BOM != test.bom {
+				t.Fatalf("invalid bom.expect %t, result %t", test.bom, content.BOM)
+			}
+			if content.Lines[0] != test.firstLine {
+				t.Fatalf("invalid first line.expect %s, result %s", test.firstLine, content.Lines[0])
+			}
+
+			// written in original encoding
+			if err := fileAccessor.WriteAll(path, content); err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("can not read test file.%#v", err)
+			}
+			if string(b) != test.text {
+				t.Fatalf("invalid round trip.expect %q, result %q", test.text, string(b))
+			}
+		})
+	}
+	t.Run("added line in crlf", func(t *testing.T) {
+		fileAccessor := &FileAccesser{}
+		path := filepath.Join(dir, "main.bat")
+		if err := ioutil.WriteFile(path, []byte("echo a\r\n"), 0644); err != nil {
+			t.Fatalf("can not write test file.%#v", err)
+		}
+		content, err := fileAccessor.ReadAll(path)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		content.Lines = append(content.Lines, "rem ADD")
+		if err := fileAccessor.WriteAll(path, content); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		b, _ := ioutil.ReadFile(path)
+		if string(b) != "echo a\r\nrem ADD\r\n" {
+			t.Fatalf("invalid line ending %q", string(b))
+		}
+	})
+	t.Run("added line in mostly crlf", func(t *testing.T) {
+		fileAccessor := &FileAccesser{}
+		path := filepath.Join(dir, "main.bat")
+		if err := ioutil.WriteFile(path, []byte("echo a\r\necho b\necho c\r\n"), 0644); err != nil {
+			t.Fatalf("can not write test file.%#v", err)
+		}
+		content, err := fileAccessor.ReadAll(path)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if !content.MixedLineEnding || content.Lines[1] != "echo b\n" {
+			t.Fatalf("invalid content %#v", content)
+		}
+		commentStyle := CommentStyle{LinePrefix: "rem"}
+		content.Lines = []string{content.Lines[0], "rem ADD", commentStyle.Comment(content.Lines[1]), content.Lines[2]}
+		if err := fileAccessor.WriteAll(path, content); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		b, _ := ioutil.ReadFile(path)
+		if string(b) != "echo a\r\nrem ADD\r\nrem echo b\necho c\r\n" {
+			t.Fatalf("invalid line ending %q", string(b))
+		}
+		lines, err := DecodeLines(content, []string{"echo b", "echo c\r"})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if lines[0] != "echo b\n" || lines[1] != "echo c" {
+			t.Fatalf("invalid decoded lines %#v", lines)
+		}
+	})
+	t.Run("not encodable text", func(t *testing.T) {
+		fileAccessor := &FileAccesser{}
+		content := FileContent{Lines: []string{"// 😀"}, Encoding: "shift_jis"}
+		if err := fileAccessor.WriteAll(filepath.Join(dir, "main.bas"), content); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestFileAccesserDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileAccessor := &FileAccesser{}
+	fileAccessor.SetDir(dir)
+	if err := fileAccessor.WriteAll("./main.go", FileContent{Lines: []string{"package main"}}); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+		t.Fatalf("file is not written in dir.%#v", err)
+	}
+	content, err := fileAccessor.ReadAll("./main.go")
+	if err != nil || len(content.Lines) != 1 {
+		t.Fatalf("invalid return %#v, %#v", content, err)
+	}
+	if err := fileAccessor.RemoveFile("./main.go"); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); !os.IsNotExist(err) {
+		t.Fatalf("file is not removed.%#v", err)
+	}
+}
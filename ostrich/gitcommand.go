@@ -1,8 +1,31 @@
 package ostrich
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
+
+// header lines of Show start with NUL.NUL can not be in commit message.
+const (
+	showFieldPrefix  = "\x00"
+	showFieldMessage = "message"
+	showFieldEnd     = "end"
+	showFormat       = "%x00commit %H%n" +
+		"%x00author %an%n" +
+		"%x00author-email %ae%n" +
+		"%x00author-date %aI%n" +
+		"%x00committer %cn%n" +
+		"%x00committer-email %ce%n" +
+		"%x00committer-date %cI%n" +
+		"%x00message%n%B" +
+		"%x00end"
+)
+
+// ErrPushRejected is error when remote branch is updated by other push.
+var ErrPushRejected = errors.New("push is rejected")
+
 type GitCommand struct {
 	executor CommandExecutorInterface
 }
@@ -12,11 +35,47 @@ func (g *GitCommand) Clone(repository string) error {
 	return err
 }
 
+// Checkout is checkout existing local branch.
 func (g *GitCommand) Checkout(branch string) error {
-	_, err := g.executor.ExecCommand("git", []string{"checkout", "-b", branch})
+	_, err := g.executor.ExecCommand("git", []string{"checkout", branch})
+	return err
+}
+
+// CheckoutTrack is create local branch tracking remote branch.
+func (g *GitCommand) CheckoutTrack(branch string) error {
+	_, err := g.executor.ExecCommand("git", []string{"checkout", "-b", branch, "--track", fmt.Sprintf("origin/%s", branch)})
+	return err
+}
+
+// CheckoutNew is create branch from start point.
+func (g *GitCommand) CheckoutNew(branch string, startPoint string) error {
+	_, err := g.executor.ExecCommand("git", []string{"checkout", "-b", branch, startPoint})
+	return err
+}
+
+// CheckoutOrphan is create branch without history.
+func (g *GitCommand) CheckoutOrphan(branch string) error {
+	_, err := g.executor.ExecCommand("git", []string{"checkout", "--orphan", branch})
 	return err
 }
 
+// LocalBranchExists is return true when local branch exists.
+func (g *GitCommand) LocalBranchExists(branch string) (bool, error) {
+	outs, err := g.executor.ExecCommand("git", []string{
+		"for-each-ref",
+		"--format=%(refname)",
+		fmt.Sprintf("refs/heads/%s", branch)})
+	if err != nil {
+		return false, err
+	}
+	for _, out := range outs {
+		if len(out) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (g *GitCommand) Pull(branch string) error {
 	_, err := g.executor.ExecCommand("git", []string{"pull", "origin", branch})
 	return err
@@ -26,18 +85,138 @@ func (g *GitCommand) Branch() ([]string, error) {
 	return g.executor.ExecCommand("git", []string{"branch"})
 }
 
+// Show is return commit with patch.merge commit is diff from first parent.
+// output is not changed by user git config, locale and diff tools.
 func (g *GitCommand) Show(commitId string) ([]string, error) {
-	return g.executor.ExecCommand("git", []string{"show", commitId})
+	return g.executor.ExecCommand("git", []string{
+		"-c", "core.quotepath=false",
+		"-c", "log.showSignature=false",
+		"show",
+		"--no-color",
+		"--no-ext-diff",
+		"--no-textconv",
+		"--find-renames",
+		"--find-copies",
+		"--submodule=short",
+		"--no-notes",
+		"--encoding=UTF-8",
+		"--diff-algorithm=myers",
+		"--unified=3",
+		"--src-prefix=a/",
+		"--dst-prefix=b/",
+		"--format=" + showFormat,
+		"--patch",
+		"-m",
+		"--first-parent",
+		commitId,
+	})
+}
+
+// ShowFile is return lines of file in commit as-is.
+func (g *GitCommand) ShowFile(commitId string, filepath string) ([]string, error) {
+	outs, err := g.executor.ExecCommand("git", []string{
+		"show",
+		"--no-textconv",
+		fmt.Sprintf("%s:%s", commitId, strings.TrimPrefix(filepath, "./")),
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	// last empty line is newline at end of file
+	if len(outs) > 0 && len(outs[len(outs)-1]) <= 0 {
+		outs = outs[:len(outs)-1]
+	}
+	return outs, nil
+}
+
+// DiffCached is return unified diff from commit to index.
+func (g *GitCommand) DiffCached(commitId string) ([]string, error) {
+	return g.executor.ExecCommand("git", []string{
+		"-c", "core.quotepath=false",
+		"diff",
+		"--cached",
+		"--no-color",
+		"--no-ext-diff",
+		"--no-textconv",
+		commitId,
+	})
 }
 
 func (g *GitCommand) Commit(message string) error {
 	_, err := g.executor.ExecCommand("git", []string{"commit", "-m", message})
 	return err
 }
-func (g *GitCommand) Push(branch string) error {
-	_, err := g.executor.ExecCommand("git", []string{"push", "-f", "origin", branch})
+
+// CommitAs is commit with author and date.committer name and email are used when they exist.
+// empty commit is allowed, so each source commit has one ostrich commit.
+func (g *GitCommand) CommitAs(message string, author CommitIdentity, committer CommitIdentity) error {
+	args := []string{}
+	if len(committer.Name) > 0 {
+		args = append(args, "-c", fmt.Sprintf("user.name=%s", committer.Name))
+	}
+	if len(committer.Email) > 0 {
+		args = append(args, "-c", fmt.Sprintf("user.email=%s", committer.Email))
+	}
+	args = append(args, "commit", "--allow-empty", "-m", message)
+	if len(author.Name) > 0 {
+		args = append(args, "--author", author.String())
+	}
+	if !author.Date.IsZero() {
+		args = append(args, "--date", author.Date.Format(time.RFC3339))
+	}
+	_, err := g.executor.ExecCommand("git", args)
+	return err
+}
+
+// Push is push branch only when remote branch is leaseCommitId.
+// empty leaseCommitId is remote branch not exists.rejected push returns ErrPushRejected.
+func (g *GitCommand) Push(branch string, leaseCommitId string) error {
+	_, err := g.executor.ExecCommand("git", []string{
+		"push",
+		fmt.Sprintf("--force-with-lease=refs/heads/%s:%s", branch, leaseCommitId),
+		"origin",
+		branch})
+	if err == nil {
+		return nil
+	}
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) && commandErr.OutputContains("[rejected]") {
+		return fmt.Errorf("%w.%s", ErrPushRejected, err.Error())
+	}
 	return err
 }
+
+// RemoteCommitId is return commit of remote branch at last fetch.empty is remote branch not exists.
+func (g *GitCommand) RemoteCommitId(branch string) (string, error) {
+	outs, err := g.executor.ExecCommand("git", []string{
+		"for-each-ref",
+		"--format=%(objectname)",
+		fmt.Sprintf("refs/remotes/origin/%s", branch)})
+	if err != nil {
+		return "", err
+	}
+	for _, out := range outs {
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	return "", nil
+}
+
+// HeadCommitId is return commit of HEAD.
+func (g *GitCommand) HeadCommitId() (string, error) {
+	outs, err := g.executor.ExecCommand("git", []string{"rev-parse", "HEAD"})
+	if err != nil {
+		return "", err
+	}
+	for _, out := range outs {
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	return "", nil
+}
+
 func (g *GitCommand) Version() ([]string, error) {
 	return g.executor.ExecCommand("git", []string{"--version"})
 }
@@ -47,13 +226,52 @@ func (g *GitCommand) Add(filepath string) error {
 	return err
 }
 
+// Rm is remove file from index.file already removed by read-tree is ignored.
 func (g *GitCommand) Rm(filepath string) error {
-	_, err := g.executor.ExecCommand("git", []string{"rm", filepath})
+	_, err := g.executor.ExecCommand("git", []string{"rm", "--ignore-unmatch", filepath})
+	return err
+}
+
+// RmCached is remove file from index and keep working tree.
+func (g *GitCommand) RmCached(filepath string) error {
+	_, err := g.executor.ExecCommand("git", []string{"rm", "--cached", "--ignore-unmatch", filepath})
+	return err
+}
+
+// CheckoutPath is copy file in commit to working tree and index as-is.
+func (g *GitCommand) CheckoutPath(commitId string, filepath string) error {
+	_, err := g.executor.ExecCommand("git", []string{"checkout", commitId, "--", filepath})
+	return err
+}
+
+// UpdateIndexChmod is set executable bit of file in index.
+func (g *GitCommand) UpdateIndexChmod(filepath string, executable bool) error {
+	chmod := "--chmod=-x"
+	if executable {
+		chmod = "--chmod=+x"
+	}
+	_, err := g.executor.ExecCommand("git", []string{"update-index", chmod, filepath})
+	return err
+}
+
+// UpdateIndexGitlink is set submodule commit in index.
+func (g *GitCommand) UpdateIndexGitlink(filepath string, commitId string) error {
+	_, err := g.executor.ExecCommand("git", []string{
+		"update-index",
+		"--add",
+		"--cacheinfo",
+		fmt.Sprintf("160000,%s,%s", commitId, filepath)})
 	return err
 }
 
 func (g *GitCommand) Reset(branch string) error {
-	_, err := g.executor.ExecCommand("git", []string{"reset", "--hard", fmt.Sprintf("origin/%s",branch)})
+	_, err := g.executor.ExecCommand("git", []string{"reset", "--hard", fmt.Sprintf("origin/%s", branch)})
+	return err
+}
+
+// ResetCommit is reset branch to commit.
+func (g *GitCommand) ResetCommit(commitId string) error {
+	_, err := g.executor.ExecCommand("git", []string{"reset", "--hard", commitId})
 	return err
 }
 
@@ -61,3 +279,41 @@ func (g *GitCommand) Fetch() error {
 	_, err := g.executor.ExecCommand("git", []string{"fetch"})
 	return err
 }
+
+// RevList is return commits of range from old to new along first parent.
+// merged branch commits are not listed, and merge commit is applied as diff from first parent.
+func (g *GitCommand) RevList(commitRange string) ([]string, error) {
+	outs, err := g.executor.ExecCommand("git", []string{"rev-list", "--reverse", "--topo-order", "--first-parent", commitRange})
+	if err != nil {
+		return []string{}, err
+	}
+	result := []string{}
+	for _, out := range outs {
+		if len(out) > 0 {
+			result = append(result, out)
+		}
+	}
+	return result, nil
+}
+
+func (g *GitCommand) ReadTree(commitId string) error {
+	_, err := g.executor.ExecCommand("git", []string{"read-tree", "-u", "--reset", commitId})
+	return err
+}
+
+// CheckAttr is return value of .gitattributes attribute.ex) crlf, unspecified
+func (g *GitCommand) CheckAttr(attr string, filepath string) (string, error) {
+	outs, err := g.executor.ExecCommand("git", []string{"check-attr", attr, "--", filepath})
+	if err != nil {
+		return "", err
+	}
+	// format: path: attr: value
+	for _, out := range outs {
+		terms := strings.Split(out, ": ")
+		if len(terms) < 3 {
+			continue
+		}
+		return terms[len(terms)-1], nil
+	}
+	return "unspecified", nil
+}
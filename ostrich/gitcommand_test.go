@@ -2,7 +2,9 @@ package ostrich
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 type DummyExecutor struct {
@@ -22,6 +24,53 @@ func (d *DummyExecutor) ExecCommand(command string, args []string) ([]string, er
 	return d.Result, nil
 }
 
+// DummyRecordExecutor is returning Results of joined args and recording args.
+type DummyRecordExecutor struct {
+	Results  map[string][]string
+	Commands []string
+}
+
+func (d *DummyRecordExecutor) ExecCommand(command string, args []string) ([]string, error) {
+	commandText := strings.Join(args, " ")
+	d.Commands = append(d.Commands, commandText)
+	return d.Results[commandText], nil
+}
+
+// DummyRejectExecutor is rejecting push Rejects times.show returns ShowResults of commit id, otherwise ShowResult.
+// ReadTree is called in read-tree to reset working tree.
+type DummyRejectExecutor struct {
+	Rejects     int
+	ShowResult  []string
+	ShowResults map[string][]string
+	ReadTree    func()
+	Commands    []string
+}
+
+func (d *DummyRejectExecutor) ExecCommand(command string, args []string) ([]string, error) {
+	d.Commands = append(d.Commands, args[0])
+	for _, arg := range args {
+		if arg == "show" {
+			if result, ok := d.ShowResults[args[len(args)-1]]; ok {
+				return result, nil
+			}
+			return d.ShowResult, nil
+		}
+	}
+	if args[0] == "read-tree" && d.ReadTree != nil {
+		d.ReadTree()
+	}
+	if args[0] == "push" && d.Rejects > 0 {
+		d.Rejects--
+		return []string{}, &CommandError{
+			Command: command,
+			Args:    args,
+			Stderr:  []string{" ! [rejected]        develop -> develop (stale info)"},
+			Err:     errors.New("exit status 1"),
+		}
+	}
+	return []string{}, nil
+}
+
 func TestGitClone(t *testing.T) {
 	executor := &DummyExecutor{}
 	git := GitCommand{
@@ -83,7 +132,6 @@ func TestGitCheckout(t *testing.T) {
 		expectCommand := "git"
 		expectArgs := []string{
 			"checkout",
-			"-b",
 			branch,
 		}
 		if expectCommand != executor.Command {
@@ -113,6 +161,79 @@ func TestGitCheckout(t *testing.T) {
 	})
 }
 
+func TestGitCheckoutNewBranch(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+	tests := []struct {
+		name       string
+		call       func() error
+		expectArgs []string
+	}{
+		{"track", func() error { return git.CheckoutTrack("ostrich") }, []string{"checkout", "-b", "ostrich", "--track", "origin/ostrich"}},
+		{"new", func() error { return git.CheckoutNew("ostrich", "origin/master") }, []string{"checkout", "-b", "ostrich", "origin/master"}},
+		{"orphan", func() error { return git.CheckoutOrphan("ostrich") }, []string{"checkout", "--orphan", "ostrich"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor.ReturnError = false
+			if err := test.call(); err != nil {
+				t.Fatal("invalid return.")
+			}
+			if len(test.expectArgs) != len(executor.Args) {
+				t.Fatalf("invalid args %#v", executor.Args)
+			}
+			for i, arg := range test.expectArgs {
+				if arg != executor.Args[i] {
+					t.Fatalf(
+						"invalid args %d.expect: %s, result: %s",
+						i,
+						arg,
+						executor.Args[i])
+				}
+			}
+			executor.ReturnError = true
+			if err := test.call(); err == nil {
+				t.Fatal("invalid return.")
+			}
+		})
+	}
+}
+
+func TestGitLocalBranchExists(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("exists", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{"refs/heads/ostrich", ""}
+		exists, err := git.LocalBranchExists("ostrich")
+		if err != nil || !exists {
+			t.Fatalf("invalid return %t, %#v", exists, err)
+		}
+		if executor.Args[2] != "refs/heads/ostrich" {
+			t.Fatalf("invalid args %#v", executor.Args)
+		}
+	})
+	t.Run("not exists", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{""}
+		exists, err := git.LocalBranchExists("ostrich")
+		if err != nil || exists {
+			t.Fatalf("invalid return %t, %#v", exists, err)
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		if _, err := git.LocalBranchExists("ostrich"); err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
 func TestGitPull(t *testing.T) {
 	executor := &DummyExecutor{}
 	git := GitCommand{
@@ -242,7 +363,27 @@ func TestGitShow(t *testing.T) {
 		}
 		expectCommand := "git"
 		expectArgs := []string{
+			"-c",
+			"core.quotepath=false",
+			"-c",
+			"log.showSignature=false",
 			"show",
+			"--no-color",
+			"--no-ext-diff",
+			"--no-textconv",
+			"--find-renames",
+			"--find-copies",
+			"--submodule=short",
+			"--no-notes",
+			"--encoding=UTF-8",
+			"--diff-algorithm=myers",
+			"--unified=3",
+			"--src-prefix=a/",
+			"--dst-prefix=b/",
+			"--format=" + showFormat,
+			"--patch",
+			"-m",
+			"--first-parent",
 			commitId,
 		}
 		if expectCommand != executor.Command {
@@ -334,6 +475,91 @@ func TestGitCommit(t *testing.T) {
 	})
 }
 
+func TestGitCommitAs(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter with author and committer", func(t *testing.T) {
+		executor.ReturnError = false
+		message := "ABCDEFG"
+		date, _ := time.Parse(time.RFC3339, "2020-03-31T13:15:38+09:00")
+		author := CommitIdentity{
+			Name:  "Taro Yamada",
+			Email: "taro@example.com",
+			Date:  date,
+		}
+		committer := CommitIdentity{
+			Name:  "ostrich",
+			Email: "ostrich@example.com",
+		}
+		err := git.CommitAs(message, author, committer)
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectArgs := []string{
+			"-c",
+			"user.name=ostrich",
+			"-c",
+			"user.email=ostrich@example.com",
+			"commit",
+			"--allow-empty",
+			"-m",
+			message,
+			"--author",
+			"Taro Yamada <taro@example.com>",
+			"--date",
+			"2020-03-31T13:15:38+09:00",
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("execute command parameter without identity", func(t *testing.T) {
+		executor.ReturnError = false
+		message := "ABCDEFG"
+		err := git.CommitAs(message, CommitIdentity{}, CommitIdentity{})
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectArgs := []string{
+			"commit",
+			"--allow-empty",
+			"-m",
+			message,
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.CommitAs("ABCDEFG", CommitIdentity{}, CommitIdentity{})
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
 func TestGitPush(t *testing.T) {
 	executor := &DummyExecutor{}
 	git := GitCommand{
@@ -343,14 +569,14 @@ func TestGitPush(t *testing.T) {
 	t.Run("execute command parameter and result", func(t *testing.T) {
 		executor.ReturnError = false
 		branch := "develop"
-		err := git.Push(branch)
+		err := git.Push(branch, "1111111")
 		if err != nil {
 			t.Fatal("invalid return.")
 		}
 		expectCommand := "git"
 		expectArgs := []string{
 			"push",
-			"-f",
+			"--force-with-lease=refs/heads/develop:1111111",
 			"origin",
 			branch,
 		}
@@ -374,10 +600,97 @@ func TestGitPush(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		executor.ReturnError = true
 		branch := "develop"
-		err := git.Push(branch)
+		err := git.Push(branch, "")
 		if err == nil {
 			t.Fatal("invalid return.")
 		}
+		if errors.Is(err, ErrPushRejected) {
+			t.Fatal("not rejected error is ErrPushRejected")
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		rejectedGit := GitCommand{
+			executor: &DummyRejectExecutor{Rejects: 1},
+		}
+		err := rejectedGit.Push("develop", "1111111")
+		if !errors.Is(err, ErrPushRejected) {
+			t.Fatalf("invalid return %#v", err)
+		}
+	})
+}
+
+func TestGitRemoteCommitId(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{"1111111", ""}
+		commitId, err := git.RemoteCommitId("develop")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		if commitId != "1111111" {
+			t.Fatalf("invalid commit id %s", commitId)
+		}
+		expectArgs := []string{
+			"for-each-ref",
+			"--format=%(objectname)",
+			"refs/remotes/origin/develop",
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("remote branch not exists", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{""}
+		commitId, err := git.RemoteCommitId("develop")
+		if err != nil || len(commitId) > 0 {
+			t.Fatalf("invalid return %s, %#v", commitId, err)
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		if _, err := git.RemoteCommitId("develop"); err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitHeadCommitId(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{"2222222", ""}
+		commitId, err := git.HeadCommitId()
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		if commitId != "2222222" {
+			t.Fatalf("invalid commit id %s", commitId)
+		}
+		if executor.Args[0] != "rev-parse" || executor.Args[1] != "HEAD" {
+			t.Fatalf("invalid args %#v", executor.Args)
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		if _, err := git.HeadCommitId(); err == nil {
+			t.Fatal("invalid return.")
+		}
 	})
 }
 
@@ -442,6 +755,7 @@ func TestGitRm(t *testing.T) {
 		expectCommand := "git"
 		expectArgs := []string{
 			"rm",
+			"--ignore-unmatch",
 			filename,
 		}
 		if expectCommand != executor.Command {
@@ -558,3 +872,410 @@ func TestGitFetch(t *testing.T) {
 		}
 	})
 }
+
+func TestGitRevList(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{
+			"1111111",
+			"2222222",
+			"",
+		}
+		commitRange := "aaaaaaa..bbbbbbb"
+		results, err := git.RevList(commitRange)
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"rev-list",
+			"--reverse",
+			"--topo-order",
+			"--first-parent",
+			commitRange,
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+		expectResults := []string{
+			"1111111",
+			"2222222",
+		}
+		if len(expectResults) != len(results) {
+			t.Fatalf("invalid result length.expect: %d, result: %d", len(expectResults), len(results))
+		}
+		for i, expectResult := range expectResults {
+			if expectResult != results[i] {
+				t.Fatalf(
+					"invalid result %d.expect: %s, result: %s",
+					i,
+					expectResult,
+					results[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		_, err := git.RevList("aaaaaaa..bbbbbbb")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitReadTree(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		commitId := "1111111"
+		err := git.ReadTree(commitId)
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"read-tree",
+			"-u",
+			"--reset",
+			commitId,
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.ReadTree("1111111")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitRmCached(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		err := git.RmCached("./sub")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"rm",
+			"--cached",
+			"--ignore-unmatch",
+			"./sub",
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.RmCached("./sub")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitCheckoutPath(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		err := git.CheckoutPath("1111111", "./image.png")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"checkout",
+			"1111111",
+			"--",
+			"./image.png",
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.CheckoutPath("1111111", "./image.png")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitUpdateIndexChmod(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		err := git.UpdateIndexChmod("./run.sh", true)
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"update-index",
+			"--chmod=+x",
+			"./run.sh",
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.UpdateIndexChmod("./run.sh", false)
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitUpdateIndexGitlink(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		err := git.UpdateIndexGitlink("./sub", "2222222")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectCommand := "git"
+		expectArgs := []string{
+			"update-index",
+			"--add",
+			"--cacheinfo",
+			"160000,2222222,./sub",
+		}
+		if expectCommand != executor.Command {
+			t.Fatalf(
+				"invalid command.expect: %s, result: %s",
+				expectCommand,
+				executor.Command)
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		err := git.UpdateIndexGitlink("./sub", "2222222")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitCheckAttr(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{
+			"./src/main.bat: eol: crlf",
+			"",
+		}
+		result, err := git.CheckAttr("eol", "./src/main.bat")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectArgs := []string{
+			"check-attr",
+			"eol",
+			"--",
+			"./src/main.bat",
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+		if result != "crlf" {
+			t.Fatalf("invalid result.expect: %s, result: %s", "crlf", result)
+		}
+	})
+	t.Run("no output", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{}
+		result, err := git.CheckAttr("eol", "./main.go")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		if result != "unspecified" {
+			t.Fatalf("invalid result.expect: %s, result: %s", "unspecified", result)
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		_, err := git.CheckAttr("eol", "./main.go")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
+
+func TestGitDiffCached(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+
+	t.Run("execute command parameter and result", func(t *testing.T) {
+		executor.ReturnError = false
+		executor.Result = []string{
+			"diff --git a/main.go b/main.go",
+			"",
+		}
+		results, err := git.DiffCached("ABCDEFG")
+		if err != nil {
+			t.Fatal("invalid return.")
+		}
+		expectArgs := []string{
+			"-c",
+			"core.quotepath=false",
+			"diff",
+			"--cached",
+			"--no-color",
+			"--no-ext-diff",
+			"--no-textconv",
+			"ABCDEFG",
+		}
+		if len(expectArgs) != len(executor.Args) {
+			t.Fatalf("invalid args length.expect: %d, result: %d", len(expectArgs), len(executor.Args))
+		}
+		for i, arg := range expectArgs {
+			if arg != executor.Args[i] {
+				t.Fatalf(
+					"invalid args %d.expect: %s, result: %s",
+					i,
+					arg,
+					executor.Args[i])
+			}
+		}
+		if len(results) != 2 {
+			t.Fatalf("invalid result length %d", len(results))
+		}
+	})
+	t.Run("return error", func(t *testing.T) {
+		executor.ReturnError = true
+		_, err := git.DiffCached("ABCDEFG")
+		if err == nil {
+			t.Fatal("invalid return.")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package ostrich
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HunkConflictError is error when hunk is not matched to file content.
+type HunkConflictError struct {
+	Filename   string
+	TargetLine int
+	Expected   []string
+	Actual     []string
+}
+
+func (e *HunkConflictError) Error() string {
+	lines := []string{
+		fmt.Sprintf("hunk conflict in %s at line %d", e.Filename, e.TargetLine),
+		"expected:",
+	}
+	for _, line := range e.Expected {
+		lines = append(lines, "\t"+line)
+	}
+	lines = append(lines, "actual:")
+	for _, line := range e.Actual {
+		lines = append(lines, "\t"+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findHunkLine is return line of after texts matched with context texts near target line.
+// working tree is new file, so leading texts, after texts and trailing texts are matched.
+// line is searched from target line to window lines away.
+// fuzz is max ignored context lines at far side like patch --fuzz.
+func findHunkLine(contents []string, mergeInfo OstrichMergeInfo, window int, fuzz int) (int, bool) {
+	for f := 0; f <= fuzz; f++ {
+		for offset := 0; offset <= window; offset++ {
+			if matchHunk(contents, mergeInfo, mergeInfo.targetLine-1+offset, f) {
+				return mergeInfo.targetLine + offset, true
+			}
+			if offset > 0 && matchHunk(contents, mergeInfo, mergeInfo.targetLine-1-offset, f) {
+				return mergeInfo.targetLine - offset, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// matchHunk is return true when after texts start at index and contexts are around.
+func matchHunk(contents []string, mergeInfo OstrichMergeInfo, index int, fuzz int) bool {
+	end := index + len(mergeInfo.afterTexts)
+	if index < 0 || end > len(contents) {
+		return false
+	}
+	for i, text := range mergeInfo.afterTexts {
+		if contents[index+i] != text {
+			return false
+		}
+	}
+	leadingStart := index - len(mergeInfo.leadingTexts)
+	for i := fuzz; i < len(mergeInfo.leadingTexts); i++ {
+		if leadingStart+i < 0 || contents[leadingStart+i] != mergeInfo.leadingTexts[i] {
+			return false
+		}
+	}
+	for i := 0; i < len(mergeInfo.trailingTexts)-fuzz; i++ {
+		if end+i >= len(contents) || contents[end+i] != mergeInfo.trailingTexts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hunkTexts is return new file texts of hunk.
+func hunkTexts(mergeInfo OstrichMergeInfo) []string {
+	result := []string{}
+	result = append(result, mergeInfo.leadingTexts...)
+	result = append(result, mergeInfo.afterTexts...)
+	result = append(result, mergeInfo.trailingTexts...)
+	return result
+}
+
+// newHunkConflictError is return error with file texts at target line.
+func newHunkConflictError(filename string, contents []string, mergeInfo OstrichMergeInfo) error {
+	expected := hunkTexts(mergeInfo)
+	start := mergeInfo.targetLine - 1 - len(mergeInfo.leadingTexts)
+	if start < 0 {
+		start = 0
+	}
+	end := start + len(expected)
+	if end > len(contents) {
+		end = len(contents)
+	}
+	actual := []string{}
+	if start < end {
+		actual = contents[start:end]
+	}
+	return &HunkConflictError{
+		Filename:   filename,
+		TargetLine: mergeInfo.targetLine,
+		Expected:   expected,
+		Actual:     actual,
+	}
+}
@@ -12,35 +12,67 @@ import (
 	"time"
 )
 
+// noNewlineMarker is diff line after a line without newline at end of file.
+const noNewlineMarker = "\\ "
+
+// pushRetryCount is max count of fetch and re-apply when push is rejected.
+const pushRetryCount = 3
+
 type Ostrich struct {
-	Repository    string
-	FromBranch    string
-	OstrichBranch string
-	CommitId      string
-	FileAccessor  FileAccesserInterface
+	Repository       string
+	FromBranch       string
+	OstrichBranch    string
+	CommitId         string
+	CommitIds        []string
+	CommitRange      string
+	FileAccessor     FileAccesserInterface
+	CommentStyles    *CommentStyleRegistry
+	Config           *Config
+	AuthorMap        map[string]string
+	Committer        CommitIdentity
+	DryRun           bool   // apply without commit and push
+	Accumulate       bool   // apply diff to ostrich files with comments of earlier commits
+	WorkspaceRoot    string // directory of job workspaces.empty is temp directory
+	WorkspaceCleanup string // WorkspaceCleanupAlways, WorkspaceCleanupOnSuccess or WorkspaceCleanupNever
+	DryRunResults    []DryRunResult
+	OstrichCommitId  string // pushed commit of ostrich branch
 }
 
-func (o *Ostrich) Run() error {
+// DryRunResult is diff from source commit to ostrich files.
+type DryRunResult struct {
+	CommitID string
+	Diff     []string
+}
 
-	// remove working directory
+func (o *Ostrich) Run() (err error) {
+	if err := ValidateWorkspaceCleanup(o.WorkspaceCleanup); err != nil {
+		return err
+	}
+	if err := ValidateCommitter(o.Committer); err != nil {
+		return err
+	}
 	repositoryName, err := o.getRepositoryName(o.Repository)
 	if err != nil {
 		return err
 	}
 
-	git := o.getGitCommand()
-
-	// get from branch
-	if err := os.RemoveAll(repositoryName); err != nil {
-		return err
-	}
-	if err := git.Clone(o.Repository); err != nil {
+	// each job has own workspace, so current directory is not changed
+	workspace, err := o.createWorkspace()
+	if err != nil {
 		return err
 	}
-	if err := o.chDir(repositoryName); err != nil {
+	defer func() {
+		o.removeWorkspace(workspace, err == nil)
+	}()
+
+	// get from branch
+	workspaceGit := o.getGitCommand(workspace)
+	if err := workspaceGit.Clone(o.Repository); err != nil {
 		return err
 	}
-	defer o.chDir("..")
+	repositoryDir := filepath.Join(workspace, repositoryName)
+	git := o.getGitCommand(repositoryDir)
+	o.FileAccessor.SetDir(repositoryDir)
 	o.showGitVersion(git)
 	if err := o.checkout(o.FromBranch, git); err != nil {
 		return err
@@ -51,43 +83,158 @@ func (o *Ostrich) Run() error {
 	if err := git.Fetch(); err != nil {
 		return err
 	}
+	if err := o.loadConfig(); err != nil {
+		return err
+	}
 
-	// apply commit to ostrich branch
-	commitTexts, err := git.Show(o.CommitId)
+	commitIds, err := o.getCommitIds(git)
 	if err != nil {
 		return err
 	}
-	commit, err := o.parseCommit(commitTexts)
+
+	// apply commits to ostrich branch in order
+	if err := o.checkoutOstrichBranch(git); err != nil {
+		return err
+	}
+	return o.replayCommitsWithRetry(commitIds, git)
+}
+
+// replayCommitsWithRetry is fetch and replay commits again when push is rejected by other push.
+func (o *Ostrich) replayCommitsWithRetry(commitIds []string, git GitCommand) error {
+	for retry := 0; ; retry++ {
+		err := o.replayCommits(commitIds, git)
+		if err == nil || !errors.Is(err, ErrPushRejected) || retry >= pushRetryCount {
+			return err
+		}
+		log.Printf("[WARN]: %s.retry %d", err.Error(), retry+1)
+		if err := git.Fetch(); err != nil {
+			return err
+		}
+	}
+}
+
+// replayCommits is replay commits on remote ostrich branch and push.
+// ostrich branch keeps own history, and new ostrich branch starts from from branch.
+func (o *Ostrich) replayCommits(commitIds []string, git GitCommand) error {
+	leaseCommitId, err := git.RemoteCommitId(o.OstrichBranch)
 	if err != nil {
 		return err
 	}
+	if err := o.resetOstrichBranch(commitIds, leaseCommitId, git); err != nil {
+		return err
+	}
+	for _, commitId := range commitIds {
+		if err := o.replayCommit(commitId, git); err != nil {
+			return err
+		}
+	}
+
+	if o.DryRun {
+		o.outputDebug("dry run.skip push")
+		return nil
+	}
 
-	if err := git.Checkout(o.OstrichBranch); err != nil {
+	// push to ostrich branch.fails when other push updates it after fetch
+	if err := git.Push(o.OstrichBranch, leaseCommitId); err != nil {
 		return err
 	}
-	if err := git.Reset(o.FromBranch); err != nil {
+	o.OstrichCommitId, err = git.HeadCommitId()
+	return err
+}
+
+// resetOstrichBranch is reset to remote ostrich branch, otherwise from branch.
+// new accumulate ostrich branch starts from parent of first commit to apply diff.
+func (o *Ostrich) resetOstrichBranch(commitIds []string, leaseCommitId string, git GitCommand) error {
+	if len(leaseCommitId) > 0 {
+		return git.Reset(o.OstrichBranch)
+	}
+	base := fmt.Sprintf("origin/%s", o.FromBranch)
+	if o.Accumulate && len(commitIds) > 0 {
+		base = commitIds[0] + "^"
+	}
+	// orphan branch has no commit, so only files are reset
+	if o.getConfig().Branch.New == NewBranchOrphan {
+		return git.ReadTree(base)
+	}
+	return git.ResetCommit(base)
+}
+
+// replayCommit is make one ostrich commit from source commit.
+func (o *Ostrich) replayCommit(commitId string, git GitCommand) error {
+	o.outputDebug(fmt.Sprintf("replayCommit: %s", commitId))
+	commitTexts, err := git.Show(commitId)
+	if err != nil {
 		return err
 	}
-	if err := o.applyCommit(commit, git); err != nil {
+	commit, err := o.parseCommit(commitTexts)
+	if err != nil {
 		return err
 	}
 
-	// commit and push to ostrich branch
-	if err := git.Commit(commit.Message); err != nil {
+	// working tree is same as source commit.accumulate working tree is ostrich files
+	if !o.Accumulate {
+		if err := git.ReadTree(commitId); err != nil {
+			return err
+		}
+	}
+	if err := o.applyCommit(commit, git); err != nil {
 		return err
 	}
-	if err := git.Push(o.OstrichBranch); err != nil {
+	if o.DryRun {
+		diffBase := commitId
+		if o.Accumulate {
+			diffBase = "HEAD"
+		}
+		diff, err := git.DiffCached(diffBase)
+		if err != nil {
+			return err
+		}
+		o.DryRunResults = append(o.DryRunResults, DryRunResult{
+			CommitID: commitId,
+			Diff:     diff,
+		})
+		return nil
+	}
+	author := CommitIdentity{
+		Name:  commit.Author,
+		Email: commit.AuthorEmail,
+		Date:  commit.CommitDate,
+	}
+	if err := git.CommitAs(commit.RawMessage, author, o.Committer); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (o *Ostrich) getGitCommand() GitCommand {
+// getCommitIds is return source commits to ostrich from old to new.
+func (o *Ostrich) getCommitIds(git GitCommand) ([]string, error) {
+	if len(o.CommitRange) > 0 {
+		commitIds, err := git.RevList(o.CommitRange)
+		if err != nil {
+			return []string{}, err
+		}
+		if len(commitIds) <= 0 {
+			return []string{}, fmt.Errorf("commit range has no commit %s", o.CommitRange)
+		}
+		return commitIds, nil
+	}
+	if len(o.CommitIds) > 0 {
+		return o.CommitIds, nil
+	}
+	if len(o.CommitId) > 0 {
+		return []string{o.CommitId}, nil
+	}
+	return []string{}, errors.New("commit id or commit range is must need")
+}
+
+// getGitCommand is return git command executed in dir.
+func (o *Ostrich) getGitCommand(dir string) GitCommand {
 	return GitCommand{
-		executor: &CommandExecutor{},
+		executor: &CommandExecutor{Dir: dir},
 	}
 }
 
+// parseCommit is parse git show output of GitCommand.Show format.
 func (o *Ostrich) parseCommit(commitTexts []string) (Commit, error) {
 
 	if len(commitTexts) < 5 {
@@ -97,75 +244,71 @@ func (o *Ostrich) parseCommit(commitTexts []string) (Commit, error) {
 				len(commitTexts))
 	}
 
-	getAuthor := func(text string) (string, error) {
-		terms := strings.Split(text, " ")
-		if len(terms) < 2 {
-			return "", fmt.Errorf("can not detect author %s.", text)
-		}
-		return terms[1], nil
-	}
-	getCommitDate := func(text string) (time.Time, error) {
-		terms := strings.Split(text, " ")
-		if len(terms) < 7 {
-			return time.Now(), fmt.Errorf("can not detect date %s", text)
-		}
-		// for japanese
-		text = strings.Replace(text, "Date:", "", 1)
-		text = strings.Trim(text, " ")
-		format := "Mon Jan 2 15:04:05 2006 -0700"
-		date, err := time.Parse(format, text)
-		if err != nil {
-			return time.Now(), err
-		}
-		return date, nil
-	}
-
-	// get author, commit date and message
-	author := ""
-	commitDate := time.Now()
-	message := ""
-	err := errors.New("")
-	for _, text := range commitTexts {
-		if strings.HasPrefix(text, "commit ") {
-			continue
-		}
-		if strings.HasPrefix(text, "Author") {
-			author, err = getAuthor(text)
-			if err != nil {
-				return Commit{}, err
+	// get header fields and message lines
+	fields := map[string]string{}
+	messageLines := []string{}
+	inMessage := false
+	headerEnd := -1
+	for i, text := range commitTexts {
+		if !strings.HasPrefix(text, showFieldPrefix) {
+			if inMessage {
+				messageLines = append(messageLines, text)
 			}
 			continue
 		}
-		if strings.HasPrefix(text, "Date:") {
-			commitDate, err = getCommitDate(text)
-			if err != nil {
-				return Commit{}, err
-			}
+		field := strings.TrimPrefix(text, showFieldPrefix)
+		if field == showFieldMessage {
+			inMessage = true
 			continue
 		}
-		if strings.HasPrefix(text, "diff") {
+		if field == showFieldEnd {
+			headerEnd = i
 			break
 		}
-
-		// text is commit message
-		buff := strings.Trim(text, " ")
-		if len(buff) > 1 {
-			message = message + ", " + strings.Trim(text, " ")
+		terms := strings.SplitN(field, " ", 2)
+		if len(terms) < 2 {
+			fields[terms[0]] = ""
+			continue
 		}
+		fields[terms[0]] = terms[1]
+	}
+	if headerEnd < 0 {
+		return Commit{}, errors.New("can not detect commit header end")
+	}
 
+	getDate := func(name string) (time.Time, error) {
+		date, err := time.Parse(time.RFC3339, fields[name])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("can not detect %s %s", name, fields[name])
+		}
+		return date, nil
 	}
-	if len(message) > 0 {
-		message = strings.Replace(message, ", ", "", 1)
+	commitDate, err := getDate("author-date")
+	if err != nil {
+		return Commit{}, err
 	}
+	committerDate, err := getDate("committer-date")
+	if err != nil {
+		return Commit{}, err
+	}
+
+	message, subject := joinMessageLines(messageLines)
 
-	ostrichFileInfo, err := o.parseOstrichFiles(commitTexts)
+	ostrichFileInfo, err := o.parseOstrichFiles(commitTexts[headerEnd+1:])
 	if err != nil {
 		return Commit{}, err
 	}
 
 	return Commit{
+		ID:               fields["commit"],
 		Message:          message,
-		Author:           author,
+		RawMessage:       strings.TrimRight(strings.Join(messageLines, "\n"), "\n"),
+		Subject:          subject,
+		Author:           fields["author"],
+		AuthorEmail:      fields["author-email"],
+		Committer:        fields["committer"],
+		CommitterEmail:   fields["committer-email"],
+		CommitterDate:    committerDate,
 		CommitDate:       commitDate,
 		OstrichFileInfos: ostrichFileInfo,
 	}, nil
@@ -180,9 +323,10 @@ func (o *Ostrich) parseOstrichFiles(texts []string) ([]OstrichFileInfo, error) {
 		}
 		return -1, errors.New("can not detect diff heading")
 	}
+	// empty commit and merge commit same as first parent have no diff
 	head, err := heading(texts)
 	if err != nil {
-		return []OstrichFileInfo{}, err
+		return []OstrichFileInfo{}, nil
 	}
 	result := []OstrichFileInfo{}
 	for {
@@ -201,11 +345,10 @@ func (o *Ostrich) parseOstrichFiles(texts []string) ([]OstrichFileInfo, error) {
 			break
 		} else {
 			o.outputDebug(fmt.Sprintf(
-				"block is %d to %d, error is %s\n",
+				"block is %d to %d\n",
 				head,
-				head+i,
-				err.Error()))
-			ostrichFileInfo, err := o.parseOstrichFile(texts[head : head+i])
+				head+1+i))
+			ostrichFileInfo, err := o.parseOstrichFile(texts[head : head+1+i])
 			if err != nil {
 				return []OstrichFileInfo{}, err
 			}
@@ -218,32 +361,104 @@ func (o *Ostrich) parseOstrichFiles(texts []string) ([]OstrichFileInfo, error) {
 }
 
 func (o *Ostrich) parseOstrichFile(texts []string) (OstrichFileInfo, error) {
-	// 6 is diff, index, -file, +file, @@, diff
-	if len(texts) < 6 {
+	// at least diff and extended header.pure rename and empty file has no @@
+	if len(texts) < 2 {
 		return OstrichFileInfo{}, fmt.Errorf("invalid ostrich file info texts length %d", len(texts))
 	}
-	for i := 0; i < 6; i++ {
-		o.outputDebug(fmt.Sprintf("ostricch file texts: %s", texts[i]))
-	}
 
-	// get filename and infotype
+	// get filename and infotype from extended header
 	buff := strings.Split(texts[0], " ")
+	if len(buff) < 4 {
+		return OstrichFileInfo{}, fmt.Errorf("invalid diff heading %s", texts[0])
+	}
 	filename := buff[2]
 	filename = "." + filename[1:len(filename)]
+	oldFilename := ""
 
 	infoType := OstrichFileInfoTypeModFile
-	if strings.HasPrefix(texts[1], "new file mode") {
-		infoType = OstrichFileInfoTypeNewFile
+	hasHunk := false
+	isBinary := false
+	isSubmodule := false
+	mode := ""
+	submoduleCommit := ""
+	noNewlineAtEOF := false
+	for i, text := range texts[1:] {
+		if strings.HasPrefix(text, "+Subproject commit ") {
+			submoduleCommit = strings.TrimPrefix(text, "+Subproject commit ")
+		}
+		// marker after removed line is for old file
+		if strings.HasPrefix(text, noNewlineMarker) && !strings.HasPrefix(texts[i], "-") {
+			noNewlineAtEOF = true
+		}
+	}
+	for _, text := range texts[1:] {
+		o.outputDebug(fmt.Sprintf("ostricch file texts: %s", text))
+		if strings.HasPrefix(text, "@@") {
+			hasHunk = true
+			break
+		}
+		if strings.HasPrefix(text, "Binary files ") || strings.HasPrefix(text, "GIT binary patch") {
+			isBinary = true
+		}
+		if strings.HasSuffix(text, " 160000") {
+			isSubmodule = true
+		}
+		if strings.HasPrefix(text, "new mode ") {
+			mode = strings.TrimPrefix(text, "new mode ")
+		}
+		if strings.HasPrefix(text, "new file mode") {
+			infoType = OstrichFileInfoTypeNewFile
+			mode = strings.TrimPrefix(text, "new file mode ")
+		}
+		if strings.HasPrefix(text, "deleted file mode") {
+			infoType = OstrichFileInfoTypeDelFile
+		}
+		if strings.HasPrefix(text, "rename from ") {
+			infoType = OstrichFileInfoTypeRenameFile
+			oldFilename = "./" + strings.TrimPrefix(text, "rename from ")
+		}
+		if strings.HasPrefix(text, "copy from ") {
+			infoType = OstrichFileInfoTypeCopyFile
+			oldFilename = "./" + strings.TrimPrefix(text, "copy from ")
+		}
+		if strings.HasPrefix(text, "rename to ") || strings.HasPrefix(text, "copy to ") {
+			terms := strings.SplitN(text, " to ", 2)
+			filename = "./" + terms[1]
+		}
 	}
-	if strings.HasPrefix(texts[1], "deleted file mode") {
-		infoType = OstrichFileInfoTypeDelFile
+
+	// apply as-is without comment
+	if isSubmodule {
+		infoType = OstrichFileInfoTypeSubmodule
+	} else if isBinary && infoType != OstrichFileInfoTypeDelFile {
+		infoType = OstrichFileInfoTypeBinaryFile
+	} else if len(mode) > 0 && !hasHunk && infoType == OstrichFileInfoTypeModFile {
+		infoType = OstrichFileInfoTypeModeFile
 	}
 	o.outputDebug(fmt.Sprintf("ostrich file info - filename: %s", filename))
+	o.outputDebug(fmt.Sprintf("ostrich file info - old filename: %s", oldFilename))
 	o.outputDebug(fmt.Sprintf("ostrich file info - info type: %d", infoType))
+	o.outputDebug(fmt.Sprintf("ostrich file info - mode: %s", mode))
+
+	if infoType == OstrichFileInfoTypeBinaryFile ||
+		infoType == OstrichFileInfoTypeModeFile ||
+		infoType == OstrichFileInfoTypeSubmodule {
+		return OstrichFileInfo{
+			Filename:          filename,
+			OldFilename:       oldFilename,
+			Mode:              mode,
+			SubmoduleCommit:   submoduleCommit,
+			InfoType:          infoType,
+			OstrichMergeInfos: []OstrichMergeInfo{},
+		}, nil
+	}
 
-	if infoType == OstrichFileInfoTypeDelFile {
+	if infoType == OstrichFileInfoTypeDelFile || !hasHunk {
 		return OstrichFileInfo{
 			Filename:          filename,
+			OldFilename:       oldFilename,
+			Mode:              mode,
+			NoNewlineAtEOF:    noNewlineAtEOF,
 			InfoType:          infoType,
 			OstrichMergeInfos: []OstrichMergeInfo{},
 		}, nil
@@ -258,6 +473,9 @@ func (o *Ostrich) parseOstrichFile(texts []string) (OstrichFileInfo, error) {
 	// generate ostrich merge infos
 	return OstrichFileInfo{
 		Filename:          filename,
+		OldFilename:       oldFilename,
+		Mode:              mode,
+		NoNewlineAtEOF:    noNewlineAtEOF,
 		InfoType:          infoType,
 		OstrichMergeInfos: ostrichMergeInfos,
 	}, nil
@@ -293,11 +511,10 @@ func (o *Ostrich) parseOstrichMerges(texts []string) ([]OstrichMergeInfo, error)
 			break
 		} else {
 			o.outputDebug(fmt.Sprintf(
-				"merge block is %d to %d, error is %s\n",
+				"merge block is %d to %d\n",
 				head,
-				head+i,
-				err.Error()))
-			mergeInfos, err := o.parseOstrichMerge(texts[head : head+i])
+				head+1+i))
+			mergeInfos, err := o.parseOstrichMerge(texts[head : head+1+i])
 			if err != nil {
 				return []OstrichMergeInfo{}, err
 			}
@@ -318,15 +535,18 @@ func (o *Ostrich) parseOstrichMerge(texts []string) ([]OstrichMergeInfo, error)
 	}
 
 	// getting otrich type, target line range and after text
-	getStartLine := func(text string) (int, error) {
-		// format: @@ -0,0 +1,9 @@
-		buffs := strings.Split(texts[0], " ")
-		if len(buffs) < 4 {
-			return 0, fmt.Errorf("invalid terms length in merge text.%s", text)
+	getLineRange := func(text string) (int, int, error) {
+		// format: -5,3 or +1
+		buffs := strings.Split(text[1:], ",")
+		start, err := strconv.Atoi(buffs[0])
+		if err != nil {
+			return 0, 0, err
 		}
-		buff := strings.Replace(buffs[1], "-", "", 1)
-		buffs = strings.Split(buff, ",")
-		return strconv.Atoi(buffs[0])
+		if len(buffs) < 2 {
+			return start, 1, nil
+		}
+		count, err := strconv.Atoi(buffs[1])
+		return start, count, err
 	}
 	getOstrichType := func(texts []string) OstrichType {
 		existsAdd := false
@@ -355,30 +575,6 @@ func (o *Ostrich) parseOstrichMerge(texts []string) ([]OstrichMergeInfo, error)
 		return OstrichTypeDel
 
 	}
-	getRemoveRange := func(baseLineNumber int, ostrichType OstrichType, texts []string) int {
-		if ostrichType == OstrichTypeAdd{
-			return baseLineNumber
-		}
-
-		startLine := 0
-		endLine := 0
-		for i, text := range texts {
-			if strings.HasPrefix(text, "-") {
-				startLine = i
-				break
-			}
-		}
-		for i := startLine; i < len(texts); i++ {
-			if !strings.HasPrefix(texts[i], "-") {
-				endLine = (i - 1)
-				break
-
-			}
-		}
-
-		return baseLineNumber - (endLine - startLine + 1)
-	}
-
 	getAddTexts := func(texts []string) []string {
 		result := []string{}
 		for _, text := range texts {
@@ -397,118 +593,386 @@ func (o *Ostrich) parseOstrichMerge(texts []string) ([]OstrichMergeInfo, error)
 		}
 		return result
 	}
-	generateMergeInfo := func(no int, lineNo int, texts []string) OstrichMergeInfo {
-		o.outputDebug(fmt.Sprintf("generate merge info %d.source text line no: %d", no, lineNo))
+	generateMergeInfo := func(no int, lineNo int, sourceLineNo int, texts []string, leadingTexts []string) OstrichMergeInfo {
+		o.outputDebug(fmt.Sprintf("generate merge info %d.target text line no: %d", no, lineNo))
 		for _, text := range texts {
 			o.outputDebug(fmt.Sprintf("\t%s", text))
 		}
-		ostrichType := getOstrichType(texts)
-		return OstrichMergeInfo {
-			no: no,
-			ostrichType: ostrichType,
-			targetLine: getRemoveRange(lineNo, ostrichType, texts),
-			removeTexts: getRemoveTexts(texts),
-			afterTexts: getAddTexts(texts),
+		return OstrichMergeInfo{
+			no:            no,
+			ostrichType:   getOstrichType(texts),
+			targetLine:    lineNo,
+			sourceLine:    sourceLineNo,
+			removeTexts:   getRemoveTexts(texts),
+			afterTexts:    getAddTexts(texts),
+			leadingTexts:  leadingTexts,
+			trailingTexts: []string{},
 		}
 	}
 
-	results := []OstrichMergeInfo{}
-	startLineNo, err := getStartLine(texts[0])
+	// format: @@ -0,0 +1,9 @@
+	buffs := strings.Split(texts[0], " ")
+	if len(buffs) < 4 {
+		return []OstrichMergeInfo{}, fmt.Errorf("invalid terms length in merge text.%s", texts[0])
+	}
+	oldStartLineNo, oldRest, err := getLineRange(buffs[1])
 	if err != nil {
 		return []OstrichMergeInfo{}, err
 	}
-	o.outputDebug(fmt.Sprintf("merge start line: %d", startLineNo))
+	newStartLineNo, newRest, err := getLineRange(buffs[2])
+	if err != nil {
+		return []OstrichMergeInfo{}, err
+	}
+	// empty range start is previous line
+	if oldRest <= 0 {
+		oldStartLineNo++
+	}
+	if newRest <= 0 {
+		newStartLineNo++
+	}
+	o.outputDebug(fmt.Sprintf("merge start line: %d", newStartLineNo))
+
+	// target line is line of new file because working tree is new file
+	results := []OstrichMergeInfo{}
 	mergeInfoNo := 0
-	sourceTextLineNo := startLineNo
+	targetTextLineNo := newStartLineNo
+	blockLineNo := newStartLineNo
+	sourceTextLineNo := oldStartLineNo
+	blockSourceLineNo := oldStartLineNo
 	buffer := []string{}
+	contexts := []string{}
 	for i, text := range texts[1:] {
+		// rest is not hunk.ex) last empty line of git output
+		if oldRest <= 0 && newRest <= 0 {
+			break
+		}
 		o.outputDebug(fmt.Sprintf("merge text %d: %s", i, text))
+		// marker is not a line of file
+		if strings.HasPrefix(text, noNewlineMarker) {
+			continue
+		}
 		if strings.HasPrefix(text, " ") || len(text) <= 0 {
 			if len(buffer) != 0 {
 				mergeInfoNo++
-				mergeInfo := generateMergeInfo(mergeInfoNo, sourceTextLineNo, buffer)
+				mergeInfo := generateMergeInfo(mergeInfoNo, blockLineNo, blockSourceLineNo, buffer, contexts)
 				results = append(results, mergeInfo)
-				buffer = buffer[:0]
+				buffer = []string{}
+				contexts = []string{}
 			}
+			contexts = append(contexts, strings.Replace(text, " ", "", 1))
+			targetTextLineNo++
 			sourceTextLineNo++
+			oldRest--
+			newRest--
 			continue
 		}
+		if len(buffer) == 0 {
+			blockLineNo = targetTextLineNo
+			blockSourceLineNo = sourceTextLineNo
+			// contexts between blocks are trailing of previous block
+			if len(results) > 0 {
+				results[len(results)-1].trailingTexts = contexts
+			}
+		}
+		if strings.HasPrefix(text, "+") {
+			targetTextLineNo++
+			newRest--
+		}
 		if strings.HasPrefix(text, "-") {
 			sourceTextLineNo++
+			oldRest--
 		}
 		o.outputDebug("add to buffer")
 		buffer = append(buffer, text)
 	}
-	if len(buffer) != 0{
+	if len(buffer) != 0 {
 		mergeInfoNo++
-		mergeInfo := generateMergeInfo(mergeInfoNo, sourceTextLineNo, buffer)
+		mergeInfo := generateMergeInfo(mergeInfoNo, blockLineNo, blockSourceLineNo, buffer, contexts)
 		results = append(results, mergeInfo)
+	} else if len(results) > 0 {
+		results[len(results)-1].trailingTexts = contexts
 	}
-	return results ,nil
+	return results, nil
 }
 
 func (o *Ostrich) applyCommit(commit Commit, git GitCommand) error {
 	o.outputDebug("applyCommit")
-	comment := o.generateOstrichCommentBase(commit)
+	config := o.getConfig()
 	for _, ostrichFileInfo := range commit.OstrichFileInfos {
-		if err := o.applyOstrichFileInfo(comment, ostrichFileInfo, git); err != nil {
+		if !config.IsTarget(ostrichFileInfo.Filename) {
+			o.outputDebug(fmt.Sprintf("skip not target file: %s", ostrichFileInfo.Filename))
+			if o.Accumulate {
+				if err := o.applySourceFile(commit, ostrichFileInfo, git); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		comment, err := o.generateOstrichCommentBase(commit, ostrichFileInfo.Filename)
+		if err != nil {
 			return err
 		}
+		if err := o.applyOstrichFileInfo(commit, comment, ostrichFileInfo, git); err != nil {
+			if !errors.Is(err, ErrNoCommentStyle) {
+				return err
+			}
+			// file can not have comment, so it is same as source.ex) package.json
+			o.outputDebug(fmt.Sprintf("%s.copy source file", err.Error()))
+			if err := o.applySourceFile(commit, ostrichFileInfo, git); err != nil {
+				return err
+			}
+			continue
+		}
+		if o.Accumulate {
+			if err := o.applyAccumulateMode(ostrichFileInfo, git); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
-func (o *Ostrich) applyOstrichFileInfo(commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+func (o *Ostrich) applyOstrichFileInfo(commit Commit, commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
 	o.outputDebug("applyOstrichFileInfo")
-	prefix, err := o.getLineCommentPrefix(ostrichFileInfo.Filename)
-	if err != nil {
-		return err
-	}
-	commentBase = prefix + " " + commentBase
-
 	switch ostrichFileInfo.InfoType {
+	case OstrichFileInfoTypeRenameFile, OstrichFileInfoTypeCopyFile:
+		return o.applyMoveOstricFile(commit, commentBase, ostrichFileInfo, git)
+	case OstrichFileInfoTypeBinaryFile:
+		return o.applyBinaryOstricFile(commit, ostrichFileInfo, git)
+	case OstrichFileInfoTypeModeFile:
+		return o.applyModeOstricFile(ostrichFileInfo, git)
+	case OstrichFileInfoTypeSubmodule:
+		return o.applySubmoduleOstricFile(ostrichFileInfo, git)
 	case OstrichFileInfoTypeNewFile:
 		return o.applyCreateOstricFile(ostrichFileInfo, git)
 	case OstrichFileInfoTypeModFile:
-		return o.applyEditOstricFile(commentBase, prefix, ostrichFileInfo, git)
+		return o.editOstrichFile(commit, commentBase, ostrichFileInfo, git)
 	case OstrichFileInfoTypeDelFile:
 		return o.applyRemoveOstricFile(ostrichFileInfo, git)
 	}
 	return nil
 }
 
+func (o *Ostrich) applyMoveOstricFile(commit Commit, commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applyMoveOstricFile")
+	if err := o.moveOstrichFile(ostrichFileInfo, git); err != nil {
+		return err
+	}
+	if len(ostrichFileInfo.OstrichMergeInfos) > 0 {
+		if err := o.editOstrichFile(commit, commentBase, ostrichFileInfo, git); err != nil {
+			return err
+		}
+	}
+
+	// header is inserted after hunks because hunk line numbers are not shifted
+	header, err := o.generateMoveHeader(commit, ostrichFileInfo)
+	if err != nil {
+		return err
+	}
+	if len(header) <= 0 {
+		return nil
+	}
+	return o.insertMoveHeader(header, ostrichFileInfo, git)
+}
+
+// moveOstrichFile is rename or copy file when working tree has only old file.
+func (o *Ostrich) moveOstrichFile(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	if _, err := o.FileAccessor.ReadAll(ostrichFileInfo.Filename); err == nil {
+		o.outputDebug(fmt.Sprintf("already moved: %s", ostrichFileInfo.Filename))
+		return nil
+	}
+	content, err := o.FileAccessor.ReadAll(ostrichFileInfo.OldFilename)
+	if err != nil {
+		return err
+	}
+	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, content); err != nil {
+		return err
+	}
+	if err := git.Add(ostrichFileInfo.Filename); err != nil {
+		return err
+	}
+	if ostrichFileInfo.InfoType == OstrichFileInfoTypeCopyFile {
+		return nil
+	}
+	if err := o.FileAccessor.RemoveFile(ostrichFileInfo.OldFilename); err != nil {
+		return err
+	}
+	return git.Rm(ostrichFileInfo.OldFilename)
+}
+
+func (o *Ostrich) generateMoveHeader(commit Commit, ostrichFileInfo OstrichFileInfo) (string, error) {
+	config := o.getConfig()
+	if len(config.Comment.RenameHeader) <= 0 {
+		return "", nil
+	}
+	ticket, err := ExtractTicket(config.Comment.TicketPattern, commit.Message)
+	if err != nil {
+		return "", err
+	}
+	header := RenderCommentTemplate(
+		config.Comment.RenameHeader,
+		config.Comment.DateFormat,
+		CommentTemplateValues{
+			Commit:      commit,
+			AuthorName:  o.AuthorMap[strings.ToLower(commit.AuthorEmail)],
+			CommitID:    commit.ID,
+			Branch:      o.FromBranch,
+			Filename:    ostrichFileInfo.Filename,
+			OldFilename: ostrichFileInfo.OldFilename,
+			Ticket:      ticket,
+		})
+	label := config.Labels.Rename
+	if ostrichFileInfo.InfoType == OstrichFileInfoTypeCopyFile {
+		label = config.Labels.Copy
+	}
+	return strings.Replace(header, "{OSTRICH_TYPE}", label, -1), nil
+}
+
+// insertMoveHeader is insert header to first line.shebang and xml declaration are kept first.
+func (o *Ostrich) insertMoveHeader(header string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	content, err := o.FileAccessor.ReadAll(ostrichFileInfo.Filename)
+	if err != nil {
+		return err
+	}
+	content, err = o.applyLineEndingAttr(content, ostrichFileInfo.Filename, git)
+	if err != nil {
+		return err
+	}
+	contents := content.Lines
+	firstLine := ""
+	if len(contents) > 0 {
+		firstLine = contents[0]
+	}
+	commentStyle, err := o.getCommentStyle(ostrichFileInfo.Filename, firstLine)
+	if err != nil {
+		return err
+	}
+	position := 0
+	if strings.HasPrefix(firstLine, "#!") || strings.HasPrefix(firstLine, "<?xml") {
+		position = 1
+	}
+	resultConetnts := []string{}
+	resultConetnts = append(resultConetnts, contents[:position]...)
+	resultConetnts = append(resultConetnts, commentStyle.Comment(header))
+	resultConetnts = append(resultConetnts, contents[position:]...)
+	content.Lines = resultConetnts
+	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, content); err != nil {
+		return err
+	}
+	return git.Add(ostrichFileInfo.Filename)
+}
+
+func (o *Ostrich) applyBinaryOstricFile(commit Commit, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applyBinaryOstricFile")
+	if err := git.CheckoutPath(commit.ID, ostrichFileInfo.Filename); err != nil {
+		return err
+	}
+	if len(ostrichFileInfo.OldFilename) <= 0 {
+		return nil
+	}
+	// binary rename
+	return git.Rm(ostrichFileInfo.OldFilename)
+}
+
+func (o *Ostrich) applyModeOstricFile(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applyModeOstricFile")
+	return git.UpdateIndexChmod(ostrichFileInfo.Filename, ostrichFileInfo.Mode == "100755")
+}
+
+func (o *Ostrich) applySubmoduleOstricFile(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+	o.outputDebug("applySubmoduleOstricFile")
+	if len(ostrichFileInfo.SubmoduleCommit) <= 0 {
+		return git.RmCached(ostrichFileInfo.Filename)
+	}
+	return git.UpdateIndexGitlink(ostrichFileInfo.Filename, ostrichFileInfo.SubmoduleCommit)
+}
+
 func (o *Ostrich) applyCreateOstricFile(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
 	o.outputDebug("applyCreateOstricFile")
-	ostrichMergeInfo := ostrichFileInfo.OstrichMergeInfos[0]
-	err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, ostrichMergeInfo.afterTexts)
+	// empty file has no merge info
+	afterTexts := []string{}
+	if len(ostrichFileInfo.OstrichMergeInfos) > 0 {
+		afterTexts = ostrichFileInfo.OstrichMergeInfos[0].afterTexts
+	}
+	content, err := o.applyLineEndingAttr(FileContent{
+		Lines:          afterTexts,
+		NoNewlineAtEOF: ostrichFileInfo.NoNewlineAtEOF,
+	}, ostrichFileInfo.Filename, git)
 	if err != nil {
 		return err
 	}
+	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, content); err != nil {
+		return err
+	}
 	if err := git.Add(ostrichFileInfo.Filename); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (o *Ostrich) applyEditOstricFile(commentBase string, commentPrefix string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
+// applyLineEndingAttr is use eol of .gitattributes when specified, otherwise detected line ending.
+func (o *Ostrich) applyLineEndingAttr(content FileContent, filename string, git GitCommand) (FileContent, error) {
+	eol, err := git.CheckAttr("eol", filename)
+	if err != nil {
+		return FileContent{}, err
+	}
+	switch eol {
+	case "crlf":
+		content.LineEnding = LineEndingCRLF
+	case "lf":
+		content.LineEnding = LineEndingLF
+	default:
+		return content, nil
+	}
+	o.outputDebug(fmt.Sprintf("eol of %s is %s", filename, eol))
+	content.Lines = TrimCarriageReturns(content.Lines)
+	content.MixedLineEnding = false
+	return content, nil
+}
+
+func (o *Ostrich) applyEditOstricFile(commentBase string, ostrichFileInfo OstrichFileInfo, git GitCommand) error {
 	o.outputDebug("applyEditOstricFile")
-	contents, err := o.FileAccessor.ReadAll(ostrichFileInfo.Filename)
+	content, err := o.FileAccessor.ReadAll(ostrichFileInfo.Filename)
+	if err != nil {
+		return err
+	}
+	content, err = o.applyLineEndingAttr(content, ostrichFileInfo.Filename, git)
+	if err != nil {
+		return err
+	}
+	contents := content.Lines
+	firstLine := ""
+	if len(contents) > 0 {
+		firstLine = contents[0]
+	}
+	commentStyle, err := o.getCommentStyle(ostrichFileInfo.Filename, firstLine)
 	if err != nil {
 		return err
 	}
+	commentBase = commentStyle.Comment(commentBase)
+	// apply from last line because line of upper hunk is not shifted
 	sort.Slice(
 		ostrichFileInfo.OstrichMergeInfos,
 		func(i, j int) bool {
-			return ostrichFileInfo.OstrichMergeInfos[i].no > ostrichFileInfo.OstrichMergeInfos[j].no
+			return ostrichFileInfo.OstrichMergeInfos[i].targetLine > ostrichFileInfo.OstrichMergeInfos[j].targetLine
 		})
 	for _, mergeInfo := range ostrichFileInfo.OstrichMergeInfos {
-		contents, err = o.applyOstrichMergeInfo(commentBase, commentPrefix, contents, mergeInfo)
+		mergeInfo, err = o.decodeOstrichMergeInfo(content, mergeInfo)
+		if err != nil {
+			return err
+		}
+		mergeInfo.targetLine, err = o.findOstrichMergeInfoLine(ostrichFileInfo.Filename, contents, mergeInfo)
+		if err != nil {
+			return err
+		}
+		contents, err = o.applyOstrichMergeInfo(commentBase, commentStyle, contents, mergeInfo)
 		if err != nil {
 			return err
 		}
 	}
-	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, contents); err != nil {
+	content.Lines = contents
+	if err := o.FileAccessor.WriteAll(ostrichFileInfo.Filename, content); err != nil {
 		return err
 	}
 	if err := git.Add(ostrichFileInfo.Filename); err != nil {
@@ -516,14 +980,53 @@ func (o *Ostrich) applyEditOstricFile(commentBase string, commentPrefix string,
 	}
 	return nil
 }
-func (o *Ostrich) applyOstrichMergeInfo(commentBase string, commentPrefix string, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
+
+// decodeOstrichMergeInfo is convert diff texts to encoding of file content.
+func (o *Ostrich) decodeOstrichMergeInfo(content FileContent, mergeInfo OstrichMergeInfo) (OstrichMergeInfo, error) {
+	removeTexts, err := DecodeLines(content, mergeInfo.removeTexts)
+	if err != nil {
+		return OstrichMergeInfo{}, err
+	}
+	afterTexts, err := DecodeLines(content, mergeInfo.afterTexts)
+	if err != nil {
+		return OstrichMergeInfo{}, err
+	}
+	leadingTexts, err := DecodeLines(content, mergeInfo.leadingTexts)
+	if err != nil {
+		return OstrichMergeInfo{}, err
+	}
+	trailingTexts, err := DecodeLines(content, mergeInfo.trailingTexts)
+	if err != nil {
+		return OstrichMergeInfo{}, err
+	}
+	mergeInfo.removeTexts = removeTexts
+	mergeInfo.afterTexts = afterTexts
+	mergeInfo.leadingTexts = leadingTexts
+	mergeInfo.trailingTexts = trailingTexts
+	return mergeInfo, nil
+}
+
+// findOstrichMergeInfoLine is return line matched hunk texts.line number of hunk is not trusted.
+func (o *Ostrich) findOstrichMergeInfoLine(filename string, contents []string, mergeInfo OstrichMergeInfo) (int, error) {
+	config := o.getConfig()
+	line, ok := findHunkLine(contents, mergeInfo, config.Apply.SearchWindow, config.Apply.Fuzz)
+	if !ok {
+		return 0, newHunkConflictError(filename, contents, mergeInfo)
+	}
+	if line != mergeInfo.targetLine {
+		o.outputDebug(fmt.Sprintf("hunk of %s line %d is found at line %d", filename, mergeInfo.targetLine, line))
+	}
+	return line, nil
+}
+
+func (o *Ostrich) applyOstrichMergeInfo(commentBase string, commentStyle CommentStyle, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
 	switch mergeInfo.ostrichType {
 	case OstrichTypeAdd:
 		return o.applyOstrichMergeInfoAdd(commentBase, contents, mergeInfo)
 	case OstrichTypeMod:
-		return o.applyOstrichMergeInfoMod(commentBase, commentPrefix, contents, mergeInfo)
+		return o.applyOstrichMergeInfoMod(commentBase, commentStyle, contents, mergeInfo)
 	case OstrichTypeDel:
-		return o.applyOstrichMergeInfoDel(commentBase, commentPrefix, contents, mergeInfo)
+		return o.applyOstrichMergeInfoDel(commentBase, commentStyle, contents, mergeInfo)
 	}
 
 	// can not arrived here
@@ -531,16 +1034,19 @@ func (o *Ostrich) applyOstrichMergeInfo(commentBase string, commentPrefix string
 }
 
 func (o *Ostrich) applyOstrichMergeInfoAdd(commentBase string, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
-	rangeComments := o.generateOstrichComment(commentBase, "ADD")
+	if err := o.checkOstrichMergeInfoRange(contents, mergeInfo); err != nil {
+		return []string{}, err
+	}
+	rangeComments := o.generateOstrichComment(commentBase, o.getConfig().Labels.Add)
 	lineIndent := o.getLineIndent(mergeInfo.afterTexts[0])
 
 	firstHalf := contents[:mergeInfo.targetLine-1]
-	latterHalf := contents[mergeInfo.targetLine + len(mergeInfo.afterTexts) -1 : len(contents)]
+	latterHalf := contents[mergeInfo.targetLine+len(mergeInfo.afterTexts)-1 : len(contents)]
 	resultConetnts := []string{}
 	resultConetnts = append(resultConetnts, firstHalf...)
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[0])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[0])
 	resultConetnts = append(resultConetnts, mergeInfo.afterTexts...)
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[1])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[1])
 	resultConetnts = append(resultConetnts, latterHalf...)
 
 	o.outputDebug("result contents")
@@ -550,26 +1056,29 @@ func (o *Ostrich) applyOstrichMergeInfoAdd(commentBase string, contents []string
 	return resultConetnts, nil
 }
 
-func (o *Ostrich) applyOstrichMergeInfoMod(commentBase string, commentPrefix string, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
+func (o *Ostrich) applyOstrichMergeInfoMod(commentBase string, commentStyle CommentStyle, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
 	o.outputDebug("applyOstrichMergeInfoMod")
-	rangeComments := o.generateOstrichComment(commentBase, "MOD")
+	if err := o.checkOstrichMergeInfoRange(contents, mergeInfo); err != nil {
+		return []string{}, err
+	}
+	rangeComments := o.generateOstrichComment(commentBase, o.getConfig().Labels.Mod)
 	lineIndent := o.getLineIndent(mergeInfo.afterTexts[0])
 
 	firstHalf := contents[:mergeInfo.targetLine-1]
-	latterHalf := contents[mergeInfo.targetLine - 1 + len(mergeInfo.afterTexts):len(contents)]
+	latterHalf := contents[mergeInfo.targetLine-1+len(mergeInfo.afterTexts) : len(contents)]
 	resultConetnts := []string{}
 	resultConetnts = append(resultConetnts, firstHalf...)
 
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[0])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[0])
 	for _, row := range mergeInfo.removeTexts {
 		row = strings.Replace(row, lineIndent, "", 1)
-		row = lineIndent + commentPrefix + " " + row
+		row = lineIndent + commentStyle.Comment(row)
 		resultConetnts = append(resultConetnts, row)
 	}
 	for _, row := range mergeInfo.afterTexts {
 		resultConetnts = append(resultConetnts, row)
 	}
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[1])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[1])
 	resultConetnts = append(resultConetnts, latterHalf...)
 
 	o.outputDebug("result contents")
@@ -579,16 +1088,21 @@ func (o *Ostrich) applyOstrichMergeInfoMod(commentBase string, commentPrefix str
 	return resultConetnts, nil
 }
 
-func (o *Ostrich) applyOstrichMergeInfoDel(commentBase string, commentPrefix string, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
+func (o *Ostrich) applyOstrichMergeInfoDel(commentBase string, commentStyle CommentStyle, contents []string, mergeInfo OstrichMergeInfo) ([]string, error) {
 	o.outputDebug("applyOstrichMergeInfoDel")
-	rangeComments := o.generateOstrichComment(commentBase, "DEL")
+	rangeComments := o.generateOstrichComment(commentBase, o.getConfig().Labels.Del)
 
+	if err := o.checkOstrichMergeInfoRange(contents, mergeInfo); err != nil {
+		return []string{}, err
+	}
 	lineIndent := ""
 	resultConetnts := []string{}
 	latterHalf := []string{}
-	if mergeInfo.targetLine >= len(contents){
-		resultConetnts = append(resultConetnts, contents...) 
-		lineIndent = o.getLineIndent(contents[len(contents) - 1])
+	if mergeInfo.targetLine > len(contents) {
+		resultConetnts = append(resultConetnts, contents...)
+		if len(contents) > 0 {
+			lineIndent = o.getLineIndent(contents[len(contents)-1])
+		}
 	} else {
 		lineIndent = o.getLineIndent(mergeInfo.removeTexts[0])
 		firstHalf := contents[:mergeInfo.targetLine-1]
@@ -597,20 +1111,20 @@ func (o *Ostrich) applyOstrichMergeInfoDel(commentBase string, commentPrefix str
 			o.outputDebug(fmt.Sprintf("[%d]: %s", i, text))
 		}
 		resultConetnts = append(resultConetnts, firstHalf...)
-		latterHalf = contents[mergeInfo.targetLine-1:len(contents)]
+		latterHalf = contents[mergeInfo.targetLine-1 : len(contents)]
 		o.outputDebug("back half")
 		for i, text := range latterHalf {
 			o.outputDebug(fmt.Sprintf("[%d]: %s", i, text))
 		}
 	}
 
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[0])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[0])
 	for _, row := range mergeInfo.removeTexts {
 		row = strings.Replace(row, lineIndent, "", 1)
-		row = lineIndent + commentPrefix + " " + row
+		row = lineIndent + commentStyle.Comment(row)
 		resultConetnts = append(resultConetnts, row)
 	}
-	resultConetnts = append(resultConetnts, lineIndent + rangeComments[1])
+	resultConetnts = append(resultConetnts, lineIndent+rangeComments[1])
 	resultConetnts = append(resultConetnts, latterHalf...)
 
 	o.outputDebug("result contents")
@@ -620,9 +1134,22 @@ func (o *Ostrich) applyOstrichMergeInfoDel(commentBase string, commentPrefix str
 	return resultConetnts, nil
 }
 
+// checkOstrichMergeInfoRange is return error when after texts are out of contents.
+func (o *Ostrich) checkOstrichMergeInfoRange(contents []string, mergeInfo OstrichMergeInfo) error {
+	if mergeInfo.targetLine < 1 || mergeInfo.targetLine-1+len(mergeInfo.afterTexts) > len(contents) {
+		return fmt.Errorf(
+			"target line %d and %d lines is out of %d lines",
+			mergeInfo.targetLine,
+			len(mergeInfo.afterTexts),
+			len(contents))
+	}
+	return nil
+}
+
 func (o *Ostrich) applyRemoveOstricFile(ostrichFileInfo OstrichFileInfo, git GitCommand) error {
 	o.outputDebug("applyRemoveOstricFile")
-	if err := o.FileAccessor.RemoveFile(ostrichFileInfo.Filename); err != nil {
+	// file is already removed by read-tree
+	if err := o.FileAccessor.RemoveFile(ostrichFileInfo.Filename); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 	if err := git.Rm(ostrichFileInfo.Filename); err != nil {
@@ -631,26 +1158,89 @@ func (o *Ostrich) applyRemoveOstricFile(ostrichFileInfo OstrichFileInfo, git Git
 	return nil
 }
 
-func (o *Ostrich) getLineCommentPrefix(filename string) (string, error) {
-	ext := filepath.Ext(filename)
-	switch ext {
-	case ".c", ".go", ".h", ".cpp":
-		return "//", nil
-	default:
-		return "", fmt.Errorf("invalid file ext %s", ext)
+func (o *Ostrich) getConfig() Config {
+	if o.Config == nil {
+		return NewDefaultConfig()
+	}
+	return *o.Config
+}
+
+// loadConfig is read project config file and author map file after checkout.
+func (o *Ostrich) loadConfig() error {
+	content, err := o.FileAccessor.ReadAll(ConfigFilename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		o.outputDebug(fmt.Sprintf("%s is not found. use default config", ConfigFilename))
+	} else {
+		config, err := ParseConfig(strings.Join(content.Lines, "\n"))
+		if err != nil {
+			return fmt.Errorf("invalid %s.%s", ConfigFilename, err.Error())
+		}
+		o.Config = &config
+		if o.CommentStyles == nil {
+			o.CommentStyles = NewCommentStyleRegistry()
+		}
+		config.RegisterLanguages(o.CommentStyles)
+		o.FileAccessor.SetEncodings(config.Encodings)
+	}
+
+	authorMapFile := o.getConfig().AuthorMapFile
+	if len(authorMapFile) <= 0 {
+		return nil
 	}
+	content, err = o.FileAccessor.ReadAll(authorMapFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			o.outputDebug(fmt.Sprintf("%s is not found", authorMapFile))
+			return nil
+		}
+		return err
+	}
+	authorMap, err := ParseAuthorMap(strings.Join(content.Lines, "\n"))
+	if err != nil {
+		return fmt.Errorf("invalid %s.%s", authorMapFile, err.Error())
+	}
+	o.AuthorMap = authorMap
+	return nil
 }
 
-func (o *Ostrich) generateOstrichCommentBase(commit Commit) string {
-	return fmt.Sprintf("%s {OSTRICH_TYPE} %s {RANGE_TAG}",
-		commit.CommitDate.Format("2006/01/02"),
-		commit.Author)
+func (o *Ostrich) getCommentStyle(filename string, firstLine string) (CommentStyle, error) {
+	if o.CommentStyles == nil {
+		o.CommentStyles = NewCommentStyleRegistry()
+	}
+	return o.CommentStyles.Find(filename, firstLine)
+}
+
+func (o *Ostrich) generateOstrichCommentBase(commit Commit, filename string) (string, error) {
+	config := o.getConfig()
+	ticket, err := ExtractTicket(config.Comment.TicketPattern, commit.Message)
+	if err != nil {
+		return "", err
+	}
+	commitID := commit.ID
+	if len(commitID) <= 0 {
+		commitID = o.CommitId
+	}
+	return RenderCommentTemplate(
+		config.Comment.Template,
+		config.Comment.DateFormat,
+		CommentTemplateValues{
+			Commit:     commit,
+			AuthorName: o.AuthorMap[strings.ToLower(commit.AuthorEmail)],
+			CommitID:   commitID,
+			Branch:     o.FromBranch,
+			Filename:   filename,
+			Ticket:     ticket,
+		}), nil
 }
 
 func (o *Ostrich) generateOstrichComment(commentBase string, ostrichTypeText string) []string {
-	comment := strings.Replace(commentBase, "{OSTRICH_TYPE}", ostrichTypeText, 1)
-	startComment := strings.Replace(comment, "{RANGE_TAG}", "START", 1)
-	endComment := strings.Replace(comment, "{RANGE_TAG}", "END", 1)
+	labels := o.getConfig().Labels
+	comment := strings.Replace(commentBase, "{OSTRICH_TYPE}", ostrichTypeText, -1)
+	startComment := strings.Replace(comment, "{RANGE_TAG}", labels.Start, -1)
+	endComment := strings.Replace(comment, "{RANGE_TAG}", labels.End, -1)
 	return []string{
 		startComment,
 		endComment,
@@ -678,25 +1268,56 @@ func (o *Ostrich) getLineIndent(line string) string {
 	return indent
 }
 
-func (o *Ostrich) chDir(dir string) error {
-	o.outputDebug(fmt.Sprintf("chDir: %s", dir))
-	return os.Chdir(dir)
+// checkout is checkout existing local or remote branch.
+func (o *Ostrich) checkout(branch string, git GitCommand) error {
+	exists, err := o.checkoutExisting(branch, git)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("branch %s is not found", branch)
+	}
+	return nil
 }
 
+// checkoutOstrichBranch is checkout ostrich branch, or create it by new branch policy of config.
+func (o *Ostrich) checkoutOstrichBranch(git GitCommand) error {
+	exists, err := o.checkoutExisting(o.OstrichBranch, git)
+	if err != nil || exists {
+		return err
+	}
+	policy := o.getConfig().Branch.New
+	o.outputDebug(fmt.Sprintf("create ostrich branch %s by %s policy", o.OstrichBranch, policy))
+	if policy == NewBranchOrphan {
+		return git.CheckoutOrphan(o.OstrichBranch)
+	}
+	return git.CheckoutNew(o.OstrichBranch, fmt.Sprintf("origin/%s", o.FromBranch))
+}
 
-func (o *Ostrich) checkout(branch string, git GitCommand) error {
+// checkoutExisting is checkout current, local or remote-tracking branch.false is branch not exists.
+func (o *Ostrich) checkoutExisting(branch string, git GitCommand) (bool, error) {
 	isCurrent, err := o.currentBranchIs(branch, git)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if isCurrent {
-		return nil
+		return true, nil
 	}
-
-	if err := git.Checkout(branch); err != nil {
-		return err
+	isLocal, err := git.LocalBranchExists(branch)
+	if err != nil {
+		return false, err
 	}
-	return nil
+	if isLocal {
+		return true, git.Checkout(branch)
+	}
+	remoteCommitId, err := git.RemoteCommitId(branch)
+	if err != nil {
+		return false, err
+	}
+	if len(remoteCommitId) > 0 {
+		return true, git.CheckoutTrack(branch)
+	}
+	return false, nil
 }
 
 func (o *Ostrich) currentBranchIs(branch string, git GitCommand) (bool, error) {
@@ -710,7 +1331,7 @@ func (o *Ostrich) currentBranchIs(branch string, git GitCommand) (bool, error) {
 			continue
 		}
 		// no need checkout
-		if strings.HasSuffix(branchName, branch) {
+		if strings.TrimSpace(strings.TrimPrefix(branchName, "*")) == branch {
 			return true, nil
 		}
 	}
@@ -726,7 +1347,6 @@ func (o *Ostrich) getRepositoryName(repository string) (string, error) {
 	return name, nil
 }
 
-
 func (o *Ostrich) outputDebug(message string) {
 	log.Printf("[DEBUG]: %s", message)
 }
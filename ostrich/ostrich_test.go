@@ -1,7 +1,12 @@
 package ostrich
 
 import (
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -9,17 +14,52 @@ import (
 
 type DummyFileAcccessor struct{}
 
-func (d *DummyFileAcccessor) ReadAll(filepath string) ([]string, error) {
-	return []string{}, nil
+func (d *DummyFileAcccessor) ReadAll(filepath string) (FileContent, error) {
+	return FileContent{Lines: []string{}}, nil
 }
 
-func (d *DummyFileAcccessor) WriteAll(filepath string, contents []string) error {
+func (d *DummyFileAcccessor) WriteAll(filepath string, content FileContent) error {
 	return nil
 }
 
 func (d *DummyFileAcccessor) RemoveFile(filepath string) error {
 	return nil
 }
+
+func (d *DummyFileAcccessor) SetEncodings(encodings []EncodingConfig) {
+}
+
+func (d *DummyFileAcccessor) SetDir(dir string) {
+}
+
+type DummyMapFileAccessor struct {
+	Files map[string][]string
+}
+
+func (d *DummyMapFileAccessor) ReadAll(filepath string) (FileContent, error) {
+	contents, ok := d.Files[filepath]
+	if !ok {
+		return FileContent{}, &os.PathError{Op: "open", Path: filepath, Err: os.ErrNotExist}
+	}
+	return FileContent{Lines: contents}, nil
+}
+
+func (d *DummyMapFileAccessor) WriteAll(filepath string, content FileContent) error {
+	d.Files[filepath] = content.Lines
+	return nil
+}
+
+func (d *DummyMapFileAccessor) RemoveFile(filepath string) error {
+	delete(d.Files, filepath)
+	return nil
+}
+
+func (d *DummyMapFileAccessor) SetEncodings(encodings []EncodingConfig) {
+}
+
+func (d *DummyMapFileAccessor) SetDir(dir string) {
+}
+
 func TestParseCommit(t *testing.T) {
 	ostrich := Ostrich{
 		Repository:    "",
@@ -35,6 +75,22 @@ func TestParseCommit(t *testing.T) {
 			t.Fatal("not return error")
 		}
 	})
+	t.Run("empty commit", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/empty_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commit, err := ostrich.parseCommit(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if commit.ID != "2222222222222222222222222222222222222222" || commit.Subject != "release 1.0" {
+			t.Fatalf("invalid commit %#v", commit)
+		}
+		if len(commit.OstrichFileInfos) != 0 {
+			t.Fatalf("invalid ostrich file infos %#v", commit.OstrichFileInfos)
+		}
+	})
 	t.Run("add file commit", func(t *testing.T) {
 		b, err := ioutil.ReadFile("../testdata/add_file_commit_text.txt")
 		if err != nil {
@@ -50,6 +106,9 @@ func TestParseCommit(t *testing.T) {
 		if commit.Author != "unknown" {
 			t.Fatalf("invalid author %s", commit.Author)
 		}
+		if commit.AuthorEmail != "n.miyata080825@gmail.com" {
+			t.Fatalf("invalid author email %s", commit.AuthorEmail)
+		}
 		expectDate, _ := time.Parse("2006-01-02", "2020-03-31")
 		if commit.CommitDate.Equal(expectDate) {
 			t.Fatalf("invalid commit date %s", commit.CommitDate.Format(time.RFC3339))
@@ -137,6 +196,208 @@ func TestParseCommit(t *testing.T) {
 		}
 
 	})
+	t.Run("commit with committer", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/mod_file_commit_text_committer.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commitTexts := strings.Split(string(b), "\n")
+		commit, err := ostrich.parseCommit(commitTexts)
+		if err != nil {
+			t.Fatalf("reterned error %#v", err)
+		}
+		if commit.Author != "Taro Yamada" {
+			t.Fatalf("invalid author %s", commit.Author)
+		}
+		if commit.AuthorEmail != "Taro.Yamada@example.com" {
+			t.Fatalf("invalid author email %s", commit.AuthorEmail)
+		}
+		if commit.Committer != "Hanako Suzuki" {
+			t.Fatalf("invalid committer %s", commit.Committer)
+		}
+		if commit.CommitterEmail != "hanako@example.com" {
+			t.Fatalf("invalid committer email %s", commit.CommitterEmail)
+		}
+		if commit.CommitDate.Format("2006-01-02 15:04:05") != "2020-03-31 13:35:14" {
+			t.Fatalf("invalid commit date %s", commit.CommitDate.Format(time.RFC3339))
+		}
+		if commit.CommitterDate.Format("2006-01-02 15:04:05") != "2020-04-01 09:10:00" {
+			t.Fatalf("invalid committer date %s", commit.CommitterDate.Format(time.RFC3339))
+		}
+		if commit.Message != "mod print message" {
+			t.Fatalf("invalid commit message %s", commit.Message)
+		}
+		if len(commit.OstrichFileInfos) != 1 {
+			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
+		}
+	})
+	t.Run("commit message like header", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/mod_file_commit_text_message_lines.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commitTexts := strings.Split(string(b), "\n")
+		commit, err := ostrich.parseCommit(commitTexts)
+		if err != nil {
+			t.Fatalf("reterned error %#v", err)
+		}
+		if commit.Author != "山田 太郎" {
+			t.Fatalf("invalid author %s", commit.Author)
+		}
+		expectMessage := "fix print message, diff of old message is wrong, Author: reviewed by hanako, - keep indent, Signed-off-by: Taro Yamada <taro@example.com>"
+		if commit.Message != expectMessage {
+			t.Fatalf("invalid commit message %s", commit.Message)
+		}
+		expectRawMessage := "fix print message\n\ndiff of old message is wrong\nAuthor: reviewed by hanako\n  - keep indent\n\nSigned-off-by: Taro Yamada <taro@example.com>"
+		if commit.RawMessage != expectRawMessage {
+			t.Fatalf("invalid commit raw message %q", commit.RawMessage)
+		}
+		if commit.Subject != "fix print message" {
+			t.Fatalf("invalid commit subject %s", commit.Subject)
+		}
+		if len(commit.OstrichFileInfos) != 1 {
+			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
+		}
+		if commit.OstrichFileInfos[0].Filename != "./main.go" {
+			t.Fatalf("invalid filename %s", commit.OstrichFileInfos[0].Filename)
+		}
+	})
+	t.Run("no header end", func(t *testing.T) {
+		commitTexts := []string{
+			"commit 75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+			"Author: unknown <n.miyata080825@gmail.com>",
+			"Date:   Tue Mar 31 13:35:14 2020 +0900",
+			"",
+			"    mod print message",
+			"",
+			"diff --git a/main.go b/main.go",
+		}
+		_, err := ostrich.parseCommit(commitTexts)
+		if err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("rename and copy file commit", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/rename_file_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commitTexts := strings.Split(string(b), "\n")
+		commit, err := ostrich.parseCommit(commitTexts)
+		if err != nil {
+			t.Fatalf("reterned error %#v", err)
+		}
+		if len(commit.OstrichFileInfos) != 3 {
+			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
+		}
+		expects := []OstrichFileInfo{
+			{Filename: "./cmd/main.go", OldFilename: "./main.go", InfoType: OstrichFileInfoTypeRenameFile},
+			{Filename: "./lib/util.go", OldFilename: "./util.go", InfoType: OstrichFileInfoTypeRenameFile},
+			{Filename: "./lib/util_copy.go", OldFilename: "./lib/util.go", InfoType: OstrichFileInfoTypeCopyFile},
+		}
+		expectMergeInfoLengths := []int{1, 0, 0}
+		for i, expect := range expects {
+			result := commit.OstrichFileInfos[i]
+			if result.Filename != expect.Filename || result.OldFilename != expect.OldFilename {
+				t.Fatalf("invalid filename %d.expect %s from %s, result %s from %s",
+					i, expect.Filename, expect.OldFilename, result.Filename, result.OldFilename)
+			}
+			if result.InfoType != expect.InfoType {
+				t.Fatalf("invalid info type %d.expect %d, result %d", i, expect.InfoType, result.InfoType)
+			}
+			if len(result.OstrichMergeInfos) != expectMergeInfoLengths[i] {
+				t.Fatalf("invalid merge info length %d.expect %d, result %d",
+					i, expectMergeInfoLengths[i], len(result.OstrichMergeInfos))
+			}
+		}
+	})
+	t.Run("binary, mode and submodule commit", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/binary_mode_submodule_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commitTexts := strings.Split(string(b), "\n")
+		commit, err := ostrich.parseCommit(commitTexts)
+		if err != nil {
+			t.Fatalf("reterned error %#v", err)
+		}
+		expects := []OstrichFileInfo{
+			{Filename: "./images/logo.png", InfoType: OstrichFileInfoTypeBinaryFile},
+			{Filename: "./main.go", InfoType: OstrichFileInfoTypeModFile},
+			{Filename: "./run.sh", InfoType: OstrichFileInfoTypeModeFile, Mode: "100755"},
+			{Filename: "./vendor/lib", InfoType: OstrichFileInfoTypeSubmodule, SubmoduleCommit: "2fe5969e2bae22ab59ad8457c34235e35a4747bb"},
+		}
+		if len(commit.OstrichFileInfos) != len(expects) {
+			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
+		}
+		for i, expect := range expects {
+			result := commit.OstrichFileInfos[i]
+			if result.Filename != expect.Filename {
+				t.Fatalf("invalid filename %d.expect %s, result %s", i, expect.Filename, result.Filename)
+			}
+			if result.InfoType != expect.InfoType {
+				t.Fatalf("invalid info type %d.expect %d, result %d", i, expect.InfoType, result.InfoType)
+			}
+			if len(expect.Mode) > 0 && result.Mode != expect.Mode {
+				t.Fatalf("invalid mode %d.expect %s, result %s", i, expect.Mode, result.Mode)
+			}
+			if result.SubmoduleCommit != expect.SubmoduleCommit {
+				t.Fatalf("invalid submodule commit %d.expect %s, result %s", i, expect.SubmoduleCommit, result.SubmoduleCommit)
+			}
+		}
+		if len(commit.OstrichFileInfos[1].OstrichMergeInfos) != 1 {
+			t.Fatalf("invalid merge info length %d", len(commit.OstrichFileInfos[1].OstrichMergeInfos))
+		}
+	})
+	t.Run("no newline at end of file commit", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/no_newline_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commitTexts := strings.Split(string(b), "\n")
+		commit, err := ostrich.parseCommit(commitTexts)
+		if err != nil {
+			t.Fatalf("reterned error %#v", err)
+		}
+		if len(commit.OstrichFileInfos) != 2 {
+			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
+		}
+
+		// new file without last newline
+		newFileInfo := commit.OstrichFileInfos[0]
+		if !newFileInfo.NoNewlineAtEOF {
+			t.Fatal("new file is not no newline at end of file")
+		}
+		afterTexts := newFileInfo.OstrichMergeInfos[0].afterTexts
+		if len(afterTexts) != 1 || afterTexts[0] != "hello" {
+			t.Fatalf("invalid after texts %#v", afterTexts)
+		}
+
+		// marker of old file is not a line
+		modFileInfo := commit.OstrichFileInfos[1]
+		if modFileInfo.NoNewlineAtEOF {
+			t.Fatal("modified file is no newline at end of file")
+		}
+		if len(modFileInfo.OstrichMergeInfos) != 1 {
+			t.Fatalf("invalid merge info length %d", len(modFileInfo.OstrichMergeInfos))
+		}
+		mergeInfo := modFileInfo.OstrichMergeInfos[0]
+		expectRemoveTexts := []string{"       fmt.Println(\"hello\")", "}"}
+		if len(mergeInfo.removeTexts) != len(expectRemoveTexts) {
+			t.Fatalf("invalid remove texts %#v", mergeInfo.removeTexts)
+		}
+		for i, text := range expectRemoveTexts {
+			if text != mergeInfo.removeTexts[i] {
+				t.Fatalf("invalid remove text %d, expect %s, result %s", i, text, mergeInfo.removeTexts[i])
+			}
+		}
+		if len(mergeInfo.afterTexts) != 2 {
+			t.Fatalf("invalid after texts %#v", mergeInfo.afterTexts)
+		}
+		if mergeInfo.targetLine != 8 {
+			t.Fatalf("invalid target line, expect: %d, result: %d", 8, mergeInfo.targetLine)
+		}
+	})
 	t.Run("modify file commit", func(t *testing.T) {
 		b, err := ioutil.ReadFile("../testdata/mod_file_commit_text.txt")
 		if err != nil {
@@ -152,6 +413,12 @@ func TestParseCommit(t *testing.T) {
 		if commit.Message != "mod print message" {
 			t.Fatalf("invalid commit message %s", commit.Message)
 		}
+		if commit.ID != "75f6622e3827fc3a1ae74fc9c18590b5214adcd1" {
+			t.Fatalf("invalid commit id %s", commit.ID)
+		}
+		if commit.Subject != "mod print message" {
+			t.Fatalf("invalid commit subject %s", commit.Subject)
+		}
 		if len(commit.OstrichFileInfos) != 1 {
 			t.Fatalf("invalid ostrich file infos.ostrich file info length is %d", len(commit.OstrichFileInfos))
 
@@ -279,16 +546,17 @@ func TestParseCommit(t *testing.T) {
 				"}",
 			},
 		}
+		// line of new file
 		expectTargetLines := []int{
 			10,
-			15,
+			16,
 		}
 		expectRemoveTexts := [][]string{
 			[]string{},
 			[]string{},
 		}
 
-		for i, ostrichMergeInfo := range commit.OstrichFileInfos[0].OstrichMergeInfos{
+		for i, ostrichMergeInfo := range commit.OstrichFileInfos[0].OstrichMergeInfos {
 			expectText := expectTexts[i]
 			if len(ostrichMergeInfo.afterTexts) != len(expectText) {
 				t.Fatalf(
@@ -423,14 +691,14 @@ func TestParseCommit(t *testing.T) {
 		expectTargetLines := 8
 		if expectTargetLines != ostrichMergeInfo.targetLine {
 			t.Fatalf(
-			"invalid target line, expect: %d, result: %d",
+				"invalid target line, expect: %d, result: %d",
 				expectTargetLines,
 				ostrichMergeInfo.targetLine)
 		}
 	})
 }
 
-func TestGetLineCommentPrefix(t *testing.T) {
+func TestGetCommentStyle(t *testing.T) {
 	ostrich := Ostrich{
 		Repository:    "",
 		FromBranch:    "",
@@ -444,29 +712,122 @@ func TestGetLineCommentPrefix(t *testing.T) {
 			"./main.h",
 			"./main.cpp",
 			"./main.go",
+			"./src/Main.java",
+			"./src/Program.cs",
+			"./src/main.py",
+			"./db/schema.sql",
+			"./Module1.vb",
+			"./src/PAYROLL.CBL",
+			"./build/Makefile",
+			"./Dockerfile",
 		}
 		expectResults := []string{
 			"//",
 			"//",
 			"//",
 			"//",
+			"//",
+			"//",
+			"#",
+			"--",
+			"'",
+			"*>",
+			"#",
+			"#",
 		}
 
 		for i, filename := range filenames {
-			result, err := ostrich.getLineCommentPrefix(filename)
+			style, err := ostrich.getCommentStyle(filename, "")
+			if err != nil {
+				t.Fatalf("invalid return error %s", err.Error())
+			}
+			if expectResults[i] != style.LinePrefix {
+				t.Fatalf("invalid return.expect: %s, result: %s", expectResults[i], style.LinePrefix)
+			}
+		}
+	})
+	t.Run("shebang", func(t *testing.T) {
+		firstLines := []string{
+			"#!/bin/sh",
+			"#!/usr/bin/env python3",
+			"#!/usr/bin/env -S node --harmony",
+		}
+		expectResults := []string{
+			"#",
+			"#",
+			"//",
+		}
+		for i, firstLine := range firstLines {
+			style, err := ostrich.getCommentStyle("./bin/deploy", firstLine)
 			if err != nil {
 				t.Fatalf("invalid return error %s", err.Error())
 			}
-			if expectResults[i] != result {
-				t.Fatalf("invalid return.expect: %s, result: %s", expectResults[i], result)
+			if expectResults[i] != style.LinePrefix {
+				t.Fatalf("invalid return.expect: %s, result: %s", expectResults[i], style.LinePrefix)
 			}
 		}
 	})
+	t.Run("user registered file type", func(t *testing.T) {
+		registry := NewCommentStyleRegistry()
+		registry.AddExtension("pks", CommentStyle{LinePrefix: "--"})
+		ostrich := Ostrich{
+			FileAccessor:  &DummyFileAcccessor{},
+			CommentStyles: registry,
+		}
+		style, err := ostrich.getCommentStyle("./package.PKS", "")
+		if err != nil {
+			t.Fatalf("invalid return error %s", err.Error())
+		}
+		if style.LinePrefix != "--" {
+			t.Fatalf("invalid return.expect: --, result: %s", style.LinePrefix)
+		}
+	})
+	t.Run("block comment file type", func(t *testing.T) {
+		style, err := ostrich.getCommentStyle("./WebContent/index.html", "")
+		if err != nil {
+			t.Fatalf("invalid return error %s", err.Error())
+		}
+		if style.LinePrefix != "" || style.BlockStart != "<!--" || style.BlockEnd != "-->" {
+			t.Fatalf("invalid return.result: %#v", style)
+		}
+	})
 	t.Run("invalid file type", func(t *testing.T) {
 		filename := "miyata"
-		_, err := ostrich.getLineCommentPrefix(filename)
-		if err == nil {
-			t.Fatalf("invalid return error.error is nil")
+		_, err := ostrich.getCommentStyle(filename, "")
+		if !errors.Is(err, ErrNoCommentStyle) {
+			t.Fatalf("invalid return error %#v", err)
+		}
+	})
+	t.Run("invalid file type is copied from source", func(t *testing.T) {
+		packageJSON := []string{"{", "  \"name\": \"ostrich\"", "}"}
+		fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{
+			"./package.json": packageJSON,
+		}}
+		executor := &DummyRecordExecutor{}
+		ostrich := Ostrich{FileAccessor: fileAccessor}
+		commit := Commit{
+			ID: "75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+			OstrichFileInfos: []OstrichFileInfo{{
+				Filename: "./package.json",
+				InfoType: OstrichFileInfoTypeModFile,
+				OstrichMergeInfos: []OstrichMergeInfo{{
+					ostrichType: OstrichTypeMod,
+					targetLine:  2,
+					sourceLine:  2,
+					removeTexts: []string{"  \"name\": \"sample\""},
+					afterTexts:  []string{"  \"name\": \"ostrich\""},
+				}},
+			}},
+		}
+		if err := ostrich.applyCommit(commit, GitCommand{executor: executor}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if strings.Join(fileAccessor.Files["./package.json"], "\n") != strings.Join(packageJSON, "\n") {
+			t.Fatalf("invalid file %#v", fileAccessor.Files["./package.json"])
+		}
+		checkout := "checkout 75f6622e3827fc3a1ae74fc9c18590b5214adcd1 -- ./package.json"
+		if len(executor.Commands) <= 0 || executor.Commands[len(executor.Commands)-1] != checkout {
+			t.Fatalf("invalid commands %#v", executor.Commands)
 		}
 	})
 }
@@ -490,9 +851,9 @@ func TestApplyOstrichMergeInfoAdd(t *testing.T) {
 			"row 003",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 1,
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  1,
 			afterTexts: []string{
 				"add text 01",
 				"add text 02",
@@ -536,9 +897,9 @@ func TestApplyOstrichMergeInfoAdd(t *testing.T) {
 			"    row 003",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 2,
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  2,
 			afterTexts: []string{
 				"    row new 01",
 				"    row new 02",
@@ -583,7 +944,7 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 	t.Run("remove first row", func(t *testing.T) {
 
 		comment := "// 2020/04/18 {OSTRICH_TYPE} miyatama {RANGE_TAG}"
-		commentPrefix := "//"
+		commentStyle := CommentStyle{LinePrefix: "//"}
 		contents := []string{
 			"add text 01",
 			"add text 02",
@@ -593,9 +954,9 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 			"row 003",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 1,
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  1,
 			afterTexts: []string{
 				"add text 01",
 				"add text 02",
@@ -606,7 +967,7 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 				"remove row 02",
 			},
 		}
-		resultContents, err := ostrich.applyOstrichMergeInfoMod(comment, commentPrefix, contents, mergeInfo)
+		resultContents, err := ostrich.applyOstrichMergeInfoMod(comment, commentStyle, contents, mergeInfo)
 		if err != nil {
 			t.Fatalf("invalid return error %#v", err)
 		}
@@ -637,7 +998,7 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 	t.Run("remove last row", func(t *testing.T) {
 
 		comment := "// 2020/04/18 {OSTRICH_TYPE} miyatama {RANGE_TAG}"
-		commentPrefix := "//"
+		commentStyle := CommentStyle{LinePrefix: "//"}
 		contents := []string{
 			"row 001",
 			"row 002",
@@ -647,9 +1008,9 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 			"add text 03",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 4,
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  4,
 			afterTexts: []string{
 				"add text 01",
 				"add text 02",
@@ -660,7 +1021,7 @@ func TestApplyOstrichMergeInfoMod(t *testing.T) {
 				"remove row 02",
 			},
 		}
-		resultContents, err := ostrich.applyOstrichMergeInfoMod(comment, commentPrefix, contents, mergeInfo)
+		resultContents, err := ostrich.applyOstrichMergeInfoMod(comment, commentStyle, contents, mergeInfo)
 		if err != nil {
 			t.Fatalf("invalid return error %#v", err)
 		}
@@ -701,23 +1062,23 @@ func TestApplyOstrichMergeInfoDel(t *testing.T) {
 	t.Run("remove first row", func(t *testing.T) {
 
 		comment := "// 2020/04/18 {OSTRICH_TYPE} miyatama {RANGE_TAG}"
-		commentPrefix := "//"
+		commentStyle := CommentStyle{LinePrefix: "//"}
 		contents := []string{
 			"row 001",
 			"row 002",
 			"row 003",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 1,
-			afterTexts:      []string{},
-			removeTexts:     []string{
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  1,
+			afterTexts:  []string{},
+			removeTexts: []string{
 				"remove row1",
 				"remove row2",
 			},
 		}
-		resultContents, err := ostrich.applyOstrichMergeInfoDel(comment, commentPrefix, contents, mergeInfo)
+		resultContents, err := ostrich.applyOstrichMergeInfoDel(comment, commentStyle, contents, mergeInfo)
 		if err != nil {
 			t.Fatalf("invalid return error %#v", err)
 		}
@@ -745,23 +1106,23 @@ func TestApplyOstrichMergeInfoDel(t *testing.T) {
 	t.Run("remove last row", func(t *testing.T) {
 
 		comment := "// 2020/04/18 {OSTRICH_TYPE} miyatama {RANGE_TAG}"
-		commentPrefix := "//"
+		commentStyle := CommentStyle{LinePrefix: "//"}
 		contents := []string{
 			"row 001",
 			"row 002",
 			"row 003",
 		}
 		mergeInfo := OstrichMergeInfo{
-			no:              1,
-			ostrichType:     OstrichTypeAdd,
-			targetLine: 4,
-			afterTexts:      []string{},
-			removeTexts:     []string{
+			no:          1,
+			ostrichType: OstrichTypeAdd,
+			targetLine:  4,
+			afterTexts:  []string{},
+			removeTexts: []string{
 				"remove row1",
 				"remove row2",
 			},
 		}
-		resultContents, err := ostrich.applyOstrichMergeInfoDel(comment, commentPrefix, contents, mergeInfo)
+		resultContents, err := ostrich.applyOstrichMergeInfoDel(comment, commentStyle, contents, mergeInfo)
 		if err != nil {
 			t.Fatalf("invalid return error %#v", err)
 		}
@@ -787,3 +1148,939 @@ func TestApplyOstrichMergeInfoDel(t *testing.T) {
 		}
 	})
 }
+
+func TestCommentStyleComment(t *testing.T) {
+	t.Run("line comment", func(t *testing.T) {
+		style := CommentStyle{LinePrefix: "#"}
+		result := style.Comment("echo 'a' # b")
+		if result != "# echo 'a' # b" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+	t.Run("block comment", func(t *testing.T) {
+		style := CommentStyle{BlockStart: "/*", BlockEnd: "*/"}
+		result := style.Comment("color: red;")
+		if result != "/* color: red; */" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+	t.Run("nested block end", func(t *testing.T) {
+		style := CommentStyle{BlockStart: "<!--", BlockEnd: "-->"}
+		result := style.Comment("<!-- old header --><div>")
+		if result != "<!-- <!- - old header - -><div> -->" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+	t.Run("valid xml comment", func(t *testing.T) {
+		style := CommentStyle{BlockStart: "<!--", BlockEnd: "-->"}
+		for _, text := range []string{"<!-- old header --><div>", "a---b", "i--;", "end-"} {
+			document := "<root>" + style.Comment(text) + "</root>"
+			if err := xml.Unmarshal([]byte(document), &struct{}{}); err != nil {
+				t.Fatalf("invalid xml %s.%#v", document, err)
+			}
+		}
+	})
+	t.Run("nested jsp block end", func(t *testing.T) {
+		style := CommentStyle{BlockStart: "<%--", BlockEnd: "--%>"}
+		result := style.Comment("<%-- old --%>")
+		if result != "<%-- <%-- old - -%> --%>" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+}
+
+func TestApplyOstrichMergeInfoDelBlockComment(t *testing.T) {
+	ostrich := Ostrich{
+		FileAccessor: &DummyFileAcccessor{},
+	}
+	commentStyle := CommentStyle{BlockStart: "<!--", BlockEnd: "-->"}
+	comment := commentStyle.Comment("2020/04/18 {OSTRICH_TYPE} miyatama {RANGE_TAG}")
+	contents := []string{
+		"<body>",
+		"  <p>row 002</p>",
+		"</body>",
+	}
+	mergeInfo := OstrichMergeInfo{
+		no:          1,
+		ostrichType: OstrichTypeDel,
+		targetLine:  2,
+		afterTexts:  []string{},
+		removeTexts: []string{
+			"  <p>row 001</p><!-- todo -->",
+		},
+	}
+	resultContents, err := ostrich.applyOstrichMergeInfoDel(comment, commentStyle, contents, mergeInfo)
+	if err != nil {
+		t.Fatalf("invalid return error %#v", err)
+	}
+	expectContents := []string{
+		"<body>",
+		"  <!-- 2020/04/18 DEL miyatama START -->",
+		"  <!-- <p>row 001</p><!- - todo - -> -->",
+		"  <!-- 2020/04/18 DEL miyatama END -->",
+		"  <p>row 002</p>",
+		"</body>",
+	}
+	if len(expectContents) != len(resultContents) {
+		t.Fatalf("invalid result contents row length.expect %d, result %d.", len(expectContents), len(resultContents))
+	}
+	for i, expectRow := range expectContents {
+		if expectRow != resultContents[i] {
+			t.Fatalf("invalid result contents %d row.expect %s, result %s.",
+				i,
+				expectRow,
+				resultContents[i])
+		}
+	}
+}
+
+func TestGenerateOstrichCommentBase(t *testing.T) {
+	commitDate, _ := time.Parse("2006-01-02", "2024-05-01")
+	commit := Commit{
+		ID:         "75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+		Message:    "ISSUE-123 fix rounding, add test",
+		Subject:    "ISSUE-123 fix rounding",
+		Author:     "tanaka",
+		CommitDate: commitDate,
+	}
+	t.Run("default template", func(t *testing.T) {
+		ostrich := Ostrich{
+			FileAccessor: &DummyFileAcccessor{},
+		}
+		result, err := ostrich.generateOstrichCommentBase(commit, "./main.go")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if result != "2024/05/01 {OSTRICH_TYPE} tanaka {RANGE_TAG}" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+	t.Run("custom template", func(t *testing.T) {
+		config, err := ParseConfig(`
+comment:
+  template: "[{DATE:2006-01-02}][{TICKET}][{OSTRICH_TYPE}][{AUTHOR}] {RANGE_TAG} {SHORT_COMMIT_ID} {BRANCH}:{FILE} {SUBJECT}"
+  ticketPattern: "\\bISSUE-[0-9]+\\b"
+`)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		ostrich := Ostrich{
+			FromBranch:   "master",
+			FileAccessor: &DummyFileAcccessor{},
+			Config:       &config,
+		}
+		result, err := ostrich.generateOstrichCommentBase(commit, "./src/main.go")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expect := "[2024-05-01][ISSUE-123][{OSTRICH_TYPE}][tanaka] {RANGE_TAG} 75f6622 master:src/main.go ISSUE-123 fix rounding"
+		if result != expect {
+			t.Fatalf("invalid result.expect %s, result %s", expect, result)
+		}
+		comments := ostrich.generateOstrichComment(result, "MOD")
+		if comments[0] != "[2024-05-01][ISSUE-123][MOD][tanaka] START 75f6622 master:src/main.go ISSUE-123 fix rounding" {
+			t.Fatalf("invalid start comment %s", comments[0])
+		}
+	})
+	t.Run("no ticket by default", func(t *testing.T) {
+		config, err := ParseConfig(`
+comment:
+  template: "[{TICKET}] {RANGE_TAG}"
+`)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		ostrich := Ostrich{
+			FileAccessor: &DummyFileAcccessor{},
+			Config:       &config,
+		}
+		utf8Commit := commit
+		utf8Commit.Message = "convert csv to UTF-8"
+		result, err := ostrich.generateOstrichCommentBase(utf8Commit, "./main.go")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if result != "[] {RANGE_TAG}" {
+			t.Fatalf("invalid result %s", result)
+		}
+	})
+	t.Run("unknown placeholder", func(t *testing.T) {
+		_, err := ParseConfig(`
+comment:
+  template: "{DATE} {USER} {RANGE_TAG}"
+`)
+		if err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestGetCommitIds(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+	t.Run("commit range", func(t *testing.T) {
+		executor.Result = []string{"1111111", "2222222", ""}
+		ostrich := Ostrich{
+			CommitId:    "3333333",
+			CommitRange: "aaaaaaa..bbbbbbb",
+		}
+		commitIds, err := ostrich.getCommitIds(git)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commitIds) != 2 || commitIds[0] != "1111111" || commitIds[1] != "2222222" {
+			t.Fatalf("invalid commit ids %#v", commitIds)
+		}
+	})
+	t.Run("empty commit range", func(t *testing.T) {
+		executor.Result = []string{""}
+		ostrich := Ostrich{
+			CommitRange: "aaaaaaa..aaaaaaa",
+		}
+		_, err := ostrich.getCommitIds(git)
+		if err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("commit id", func(t *testing.T) {
+		ostrich := Ostrich{
+			CommitId: "3333333",
+		}
+		commitIds, err := ostrich.getCommitIds(git)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commitIds) != 1 || commitIds[0] != "3333333" {
+			t.Fatalf("invalid commit ids %#v", commitIds)
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("no config file", func(t *testing.T) {
+		ostrich := Ostrich{
+			FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{}},
+		}
+		if err := ostrich.loadConfig(); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if ostrich.Config != nil {
+			t.Fatalf("invalid config %#v", ostrich.Config)
+		}
+	})
+	t.Run("config and author map", func(t *testing.T) {
+		ostrich := Ostrich{
+			FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{
+				".ostrich.yml": {
+					"comment:",
+					"  template: \"{DATE} {OSTRICH_TYPE} {AUTHOR}({AUTHOR_EMAIL}) {RANGE_TAG}\"",
+					"authorMapFile: authors.yml",
+				},
+				"authors.yml": {
+					"taro.yamada@example.com: E1234",
+				},
+			}},
+		}
+		if err := ostrich.loadConfig(); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		commitDate, _ := time.Parse("2006-01-02", "2020-03-31")
+		commit := Commit{
+			Author:      "Taro Yamada",
+			AuthorEmail: "Taro.Yamada@example.com",
+			CommitDate:  commitDate,
+		}
+		result, err := ostrich.generateOstrichCommentBase(commit, "./main.go")
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expect := "2020/03/31 {OSTRICH_TYPE} E1234(Taro.Yamada@example.com) {RANGE_TAG}"
+		if result != expect {
+			t.Fatalf("invalid result.expect %s, result %s", expect, result)
+		}
+	})
+	t.Run("invalid config file", func(t *testing.T) {
+		ostrich := Ostrich{
+			FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{
+				".ostrich.yml": {"labels: ["},
+			}},
+		}
+		if err := ostrich.loadConfig(); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestApplyMoveOstricFile(t *testing.T) {
+	commitDate, _ := time.Parse("2006-01-02", "2020-04-20")
+	commit := Commit{
+		Author:     "miyatama",
+		CommitDate: commitDate,
+	}
+	config := NewDefaultConfig()
+	config.Comment.RenameHeader = "{DATE} {OSTRICH_TYPE} {AUTHOR} from {OLD_FILE}"
+	git := GitCommand{
+		executor: &DummyExecutor{},
+	}
+	t.Run("rename not moved file", func(t *testing.T) {
+		fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{
+			"./util.go": {"package main", "", "func util() {}"},
+		}}
+		ostrich := Ostrich{
+			FileAccessor: fileAccessor,
+			Config:       &config,
+		}
+		ostrichFileInfo := OstrichFileInfo{
+			Filename:          "./lib/util.go",
+			OldFilename:       "./util.go",
+			InfoType:          OstrichFileInfoTypeRenameFile,
+			OstrichMergeInfos: []OstrichMergeInfo{},
+		}
+		if err := ostrich.applyOstrichFileInfo(commit, "", ostrichFileInfo, git); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if _, ok := fileAccessor.Files["./util.go"]; ok {
+			t.Fatal("old file is not removed")
+		}
+		expectContents := []string{
+			"// 2020/04/20 RENAME miyatama from util.go",
+			"package main",
+			"",
+			"func util() {}",
+		}
+		resultContents := fileAccessor.Files["./lib/util.go"]
+		if len(expectContents) != len(resultContents) {
+			t.Fatalf("invalid result contents row length.expect %d, result %d.", len(expectContents), len(resultContents))
+		}
+		for i, expectRow := range expectContents {
+			if expectRow != resultContents[i] {
+				t.Fatalf("invalid result contents %d row.expect %s, result %s.", i, expectRow, resultContents[i])
+			}
+		}
+	})
+	t.Run("copy moved file with shebang", func(t *testing.T) {
+		fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{
+			"./bin/run.sh":  {"#!/bin/sh", "echo run"},
+			"./bin/run2.sh": {"#!/bin/sh", "echo run"},
+		}}
+		ostrich := Ostrich{
+			FileAccessor: fileAccessor,
+			Config:       &config,
+		}
+		ostrichFileInfo := OstrichFileInfo{
+			Filename:          "./bin/run2.sh",
+			OldFilename:       "./bin/run.sh",
+			InfoType:          OstrichFileInfoTypeCopyFile,
+			OstrichMergeInfos: []OstrichMergeInfo{},
+		}
+		if err := ostrich.applyOstrichFileInfo(commit, "", ostrichFileInfo, git); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(fileAccessor.Files["./bin/run.sh"]) != 2 {
+			t.Fatal("source file is changed")
+		}
+		expectContents := []string{
+			"#!/bin/sh",
+			"# 2020/04/20 COPY miyatama from bin/run.sh",
+			"echo run",
+		}
+		resultContents := fileAccessor.Files["./bin/run2.sh"]
+		if len(expectContents) != len(resultContents) {
+			t.Fatalf("invalid result contents row length.expect %d, result %d.", len(expectContents), len(resultContents))
+		}
+		for i, expectRow := range expectContents {
+			if expectRow != resultContents[i] {
+				t.Fatalf("invalid result contents %d row.expect %s, result %s.", i, expectRow, resultContents[i])
+			}
+		}
+	})
+}
+
+func TestApplyLineEndingAttr(t *testing.T) {
+	executor := &DummyExecutor{}
+	git := GitCommand{
+		executor: executor,
+	}
+	ostrich := Ostrich{
+		FileAccessor: &DummyFileAcccessor{},
+	}
+	t.Run("crlf attribute", func(t *testing.T) {
+		executor.Result = []string{"./run.bat: eol: crlf", ""}
+		content, err := ostrich.applyLineEndingAttr(FileContent{Lines: []string{"echo a\r", "echo b"}}, "./run.bat", git)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if content.LineEnding != LineEndingCRLF {
+			t.Fatalf("invalid line ending %q", content.LineEnding)
+		}
+		if content.Lines[0] != "echo a" || content.Lines[1] != "echo b" {
+			t.Fatalf("invalid lines %#v", content.Lines)
+		}
+	})
+	t.Run("unspecified attribute", func(t *testing.T) {
+		executor.Result = []string{"./main.go: eol: unspecified", ""}
+		content, err := ostrich.applyLineEndingAttr(FileContent{Lines: []string{"package main"}, LineEnding: LineEndingCRLF}, "./main.go", git)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if content.LineEnding != LineEndingCRLF {
+			t.Fatalf("detected line ending is changed %q", content.LineEnding)
+		}
+	})
+}
+
+func TestApplyEditOstricFile(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/mod_file_commit_text_add_twe_parts.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	git := GitCommand{
+		executor: &DummyExecutor{},
+	}
+	comment := "{OSTRICH_TYPE} {RANGE_TAG}"
+	newContents := func() []string {
+		return []string{
+			"package main",
+			"",
+			"import (",
+			"     \"fmt\"",
+			")",
+			"",
+			"func main() {",
+			"     fmt.Println(\"hello world version 16\")",
+			"     test()",
+			"     test2()",
+			"}",
+			"",
+			"func test() {",
+			"     fmt.Println(\"this is test\")",
+			"}",
+			"",
+			"func test2() {",
+			"     fmt.Println(\"this is test\")",
+			"}",
+		}
+	}
+	t.Run("shifted lines", func(t *testing.T) {
+		ostrich := Ostrich{}
+		commit, err := ostrich.parseCommit(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		// two lines are shifted from hunk line
+		contents := append([]string{"// header 1", "// header 2"}, newContents()...)
+		fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{"./main.go": contents}}
+		ostrich.FileAccessor = fileAccessor
+		if err := ostrich.applyEditOstricFile(comment, commit.OstrichFileInfos[0], git); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expectContents := []string{
+			"// header 1",
+			"// header 2",
+			"package main",
+			"",
+			"import (",
+			"     \"fmt\"",
+			")",
+			"",
+			"func main() {",
+			"     fmt.Println(\"hello world version 16\")",
+			"     test()",
+			"     // ADD START",
+			"     test2()",
+			"     // ADD END",
+			"}",
+			"",
+			"func test() {",
+			"     fmt.Println(\"this is test\")",
+			"}",
+			"// ADD START",
+			"",
+			"func test2() {",
+			"     fmt.Println(\"this is test\")",
+			"}",
+			"// ADD END",
+		}
+		resultContents := fileAccessor.Files["./main.go"]
+		if len(expectContents) != len(resultContents) {
+			t.Fatalf("invalid result contents row length.expect %d, result %d.", len(expectContents), len(resultContents))
+		}
+		for i, expectRow := range expectContents {
+			if expectRow != resultContents[i] {
+				t.Fatalf("invalid result contents %d row.expect %s, result %s.", i, expectRow, resultContents[i])
+			}
+		}
+	})
+	t.Run("conflict", func(t *testing.T) {
+		ostrich := Ostrich{}
+		commit, err := ostrich.parseCommit(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		contents := newContents()
+		contents[9] = "     test3()"
+		ostrich.FileAccessor = &DummyMapFileAccessor{Files: map[string][]string{"./main.go": contents}}
+		err = ostrich.applyEditOstricFile(comment, commit.OstrichFileInfos[0], git)
+		if _, ok := err.(*HunkConflictError); !ok {
+			t.Fatalf("not return conflict error %#v", err)
+		}
+	})
+}
+
+func TestFindHunkLine(t *testing.T) {
+	contents := []string{
+		"row 001",
+		"row 002",
+		"row 003",
+		"row new",
+		"row 004",
+		"row 005",
+		"row 006",
+	}
+	mergeInfo := OstrichMergeInfo{
+		targetLine:    2,
+		afterTexts:    []string{"row new"},
+		leadingTexts:  []string{"row 001", "row 002", "row 003"},
+		trailingTexts: []string{"row 004", "row 005", "row 006"},
+	}
+	t.Run("search window", func(t *testing.T) {
+		line, ok := findHunkLine(contents, mergeInfo, 2, 0)
+		if !ok || line != 4 {
+			t.Fatalf("invalid line %d", line)
+		}
+		if _, ok := findHunkLine(contents, mergeInfo, 1, 0); ok {
+			t.Fatal("found out of window")
+		}
+	})
+	t.Run("fuzz", func(t *testing.T) {
+		fuzzMergeInfo := mergeInfo
+		fuzzMergeInfo.leadingTexts = []string{"row 000", "row 002", "row 003"}
+		fuzzMergeInfo.trailingTexts = []string{"row 004", "row 005", "row 007"}
+		if _, ok := findHunkLine(contents, fuzzMergeInfo, 2, 0); ok {
+			t.Fatal("found without fuzz")
+		}
+		line, ok := findHunkLine(contents, fuzzMergeInfo, 2, 1)
+		if !ok || line != 4 {
+			t.Fatalf("invalid line %d", line)
+		}
+	})
+}
+
+func TestReplayCommitDryRun(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/mod_file_commit_text.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	executor := &DummyExecutor{Result: strings.Split(string(b), "\n")}
+	git := GitCommand{
+		executor: executor,
+	}
+	fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{
+		"./main.go": {
+			"package main",
+			"",
+			"import (",
+			"       \"fmt\"",
+			")",
+			"",
+			"func main() {",
+			"       fmt.Println(\"hello world\")",
+			"}",
+		},
+	}}
+	ostrich := Ostrich{
+		FileAccessor: fileAccessor,
+		DryRun:       true,
+	}
+	if err := ostrich.replayCommit("75f6622e3827fc3a1ae74fc9c18590b5214adcd1", git); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if len(ostrich.DryRunResults) != 1 {
+		t.Fatalf("invalid dry run results length %d", len(ostrich.DryRunResults))
+	}
+	if ostrich.DryRunResults[0].CommitID != "75f6622e3827fc3a1ae74fc9c18590b5214adcd1" {
+		t.Fatalf("invalid commit id %s", ostrich.DryRunResults[0].CommitID)
+	}
+	// last command is diff, not commit
+	if executor.Args[2] != "diff" {
+		t.Fatalf("invalid last command %#v", executor.Args)
+	}
+	if len(fileAccessor.Files["./main.go"]) != 12 {
+		t.Fatalf("ostrich comment is not applied %#v", fileAccessor.Files["./main.go"])
+	}
+}
+
+func TestReplayCommitMessage(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/mod_file_commit_text_message_lines.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	executor := &DummyExecutor{Result: strings.Split(string(b), "\n")}
+	ostrich := Ostrich{
+		FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{
+			"./main.go": {
+				"package main",
+				"",
+				"import (",
+				"       \"fmt\"",
+				")",
+				"",
+				"func main() {",
+				"       fmt.Println(\"hello world\")",
+				"}",
+			},
+		}},
+	}
+	if err := ostrich.replayCommit("75f6622e3827fc3a1ae74fc9c18590b5214adcd1", GitCommand{executor: executor}); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	// last command is commit with message as-is
+	if executor.Args[0] != "commit" || executor.Args[3] != "fix print message\n\ndiff of old message is wrong\nAuthor: reviewed by hanako\n  - keep indent\n\nSigned-off-by: Taro Yamada <taro@example.com>" {
+		t.Fatalf("invalid last command %#v", executor.Args)
+	}
+}
+
+func TestReplayCommitsWithRetry(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/mod_file_commit_text.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	fileAccessor := &DummyMapFileAccessor{}
+	ostrich := Ostrich{
+		FromBranch:    "master",
+		OstrichBranch: "ostrich",
+		FileAccessor:  fileAccessor,
+	}
+	readTree := func() {
+		fileAccessor.Files = map[string][]string{
+			"./main.go": {
+				"package main",
+				"",
+				"import (",
+				"       \"fmt\"",
+				")",
+				"",
+				"func main() {",
+				"       fmt.Println(\"hello world\")",
+				"}",
+			},
+		}
+	}
+	t.Run("retry rejected push", func(t *testing.T) {
+		executor := &DummyRejectExecutor{Rejects: 1, ShowResult: strings.Split(string(b), "\n"), ReadTree: readTree}
+		if err := ostrich.replayCommitsWithRetry([]string{"75f6622e3827fc3a1ae74fc9c18590b5214adcd1"}, GitCommand{executor: executor}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		pushCount := 0
+		fetchCount := 0
+		for _, command := range executor.Commands {
+			if command == "push" {
+				pushCount++
+			}
+			if command == "fetch" {
+				fetchCount++
+			}
+		}
+		if pushCount != 2 || fetchCount != 1 {
+			t.Fatalf("invalid commands %#v", executor.Commands)
+		}
+	})
+	t.Run("empty commit in range", func(t *testing.T) {
+		empty, err := ioutil.ReadFile("../testdata/empty_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		executor := &DummyRejectExecutor{
+			ShowResults: map[string][]string{
+				"1111111": strings.Split(string(b), "\n"),
+				"2222222": strings.Split(string(empty), "\n"),
+				"3333333": strings.Split(string(b), "\n"),
+			},
+			ReadTree: readTree,
+		}
+		if err := ostrich.replayCommitsWithRetry([]string{"1111111", "2222222", "3333333"}, GitCommand{executor: executor}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		commitCount := 0
+		pushCount := 0
+		for _, command := range executor.Commands {
+			if command == "commit" {
+				commitCount++
+			}
+			if command == "push" {
+				pushCount++
+			}
+		}
+		if commitCount != 3 || pushCount != 1 {
+			t.Fatalf("invalid commands %#v", executor.Commands)
+		}
+	})
+	t.Run("always rejected", func(t *testing.T) {
+		executor := &DummyRejectExecutor{Rejects: pushRetryCount + 1, ShowResult: strings.Split(string(b), "\n"), ReadTree: readTree}
+		err := ostrich.replayCommitsWithRetry([]string{"75f6622e3827fc3a1ae74fc9c18590b5214adcd1"}, GitCommand{executor: executor})
+		if !errors.Is(err, ErrPushRejected) {
+			t.Fatalf("invalid return %#v", err)
+		}
+	})
+}
+
+func TestApplyAccumulateEditOstricFile(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/mod_file_commit_text.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	sourceContents := []string{
+		"package main",
+		"",
+		"import (",
+		"       \"fmt\"",
+		")",
+		"",
+		"func main() {",
+		"       fmt.Println(\"hello\")",
+		"}",
+	}
+	git := GitCommand{
+		executor: &DummyExecutor{Result: append(sourceContents, "")},
+	}
+	comment := "{OSTRICH_TYPE} {RANGE_TAG}"
+	ostrich := Ostrich{Accumulate: true}
+	commit, err := ostrich.parseCommit(strings.Split(string(b), "\n"))
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if commit.OstrichFileInfos[0].OstrichMergeInfos[0].sourceLine != 8 {
+		t.Fatalf("invalid source line %d", commit.OstrichFileInfos[0].OstrichMergeInfos[0].sourceLine)
+	}
+
+	t.Run("earlier comments", func(t *testing.T) {
+		fileAccessor := &DummyMapFileAccessor{Files: map[string][]string{"./main.go": {
+			"// header",
+			"package main",
+			"",
+			"import (",
+			"       \"fmt\"",
+			")",
+			"",
+			"func main() {",
+			"       // ADD START",
+			"       fmt.Println(\"hello\")",
+			"       // ADD END",
+			"}",
+		}}}
+		ostrich.FileAccessor = fileAccessor
+		if err := ostrich.applyAccumulateEditOstricFile(commit, comment, commit.OstrichFileInfos[0], git); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expectContents := []string{
+			"// header",
+			"package main",
+			"",
+			"import (",
+			"       \"fmt\"",
+			")",
+			"",
+			"func main() {",
+			"       // ADD START",
+			"       // MOD START",
+			"       // fmt.Println(\"hello\")",
+			"       fmt.Println(\"hello world\")",
+			"       // MOD END",
+			"       // ADD END",
+			"}",
+		}
+		resultContents := fileAccessor.Files["./main.go"]
+		if len(expectContents) != len(resultContents) {
+			t.Fatalf("invalid result contents row length.expect %d, result %d.", len(expectContents), len(resultContents))
+		}
+		for i, expectRow := range expectContents {
+			if expectRow != resultContents[i] {
+				t.Fatalf("invalid result contents %d row.expect %s, result %s.", i, expectRow, resultContents[i])
+			}
+		}
+	})
+	t.Run("not based on source", func(t *testing.T) {
+		ostrich.FileAccessor = &DummyMapFileAccessor{Files: map[string][]string{"./main.go": {
+			"package main",
+		}}}
+		if err := ostrich.applyAccumulateEditOstricFile(commit, comment, commit.OstrichFileInfos[0], git); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestMapSourceLines(t *testing.T) {
+	t.Run("shifted lines", func(t *testing.T) {
+		lineMap, err := mapSourceLines(
+			[]string{"row 1", "row 2", "row 3"},
+			[]string{"// comment", "row 1", "// START", "row 2", "// END", "row 3"})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expect := []int{1, 3, 5}
+		for i, index := range expect {
+			if lineMap[i] != index {
+				t.Fatalf("invalid line map %#v", lineMap)
+			}
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		if _, err := mapSourceLines([]string{"row 1", "row 2"}, []string{"row 2", "row 1"}); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestCheckoutOstrichBranch(t *testing.T) {
+	branchCommand := "branch"
+	localCommand := "for-each-ref --format=%(refname) refs/heads/ostrich"
+	remoteCommand := "for-each-ref --format=%(objectname) refs/remotes/origin/ostrich"
+	orphanConfig, err := ParseConfig("branch:\n  new: orphan\n")
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	tests := []struct {
+		name          string
+		config        *Config
+		results       map[string][]string
+		expectCommand string
+	}{
+		{"current branch", nil, map[string][]string{branchCommand: {"  master", "* ostrich"}}, branchCommand},
+		{"local branch", nil, map[string][]string{localCommand: {"refs/heads/ostrich"}}, "checkout ostrich"},
+		{"remote branch", nil, map[string][]string{remoteCommand: {"1111111"}}, "checkout -b ostrich --track origin/ostrich"},
+		{"new source branch", nil, map[string][]string{}, "checkout -b ostrich origin/master"},
+		{"new orphan branch", &orphanConfig, map[string][]string{}, "checkout --orphan ostrich"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			executor := &DummyRecordExecutor{Results: test.results}
+			ostrich := Ostrich{
+				FromBranch:    "master",
+				OstrichBranch: "ostrich",
+				Config:        test.config,
+			}
+			if err := ostrich.checkoutOstrichBranch(GitCommand{executor: executor}); err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			lastCommand := executor.Commands[len(executor.Commands)-1]
+			if lastCommand != test.expectCommand {
+				t.Fatalf("invalid command.expect: %s, result: %s", test.expectCommand, lastCommand)
+			}
+		})
+	}
+	t.Run("from branch not found", func(t *testing.T) {
+		ostrich := Ostrich{}
+		if err := ostrich.checkout("develop", GitCommand{executor: &DummyRecordExecutor{}}); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestWorkspace(t *testing.T) {
+	root, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(root)
+
+	tests := []struct {
+		cleanup     string
+		succeeded   bool
+		expectExist bool
+	}{
+		{WorkspaceCleanupAlways, false, false},
+		{WorkspaceCleanupOnSuccess, true, false},
+		{WorkspaceCleanupOnSuccess, false, true},
+		{WorkspaceCleanupNever, true, true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %t", test.cleanup, test.succeeded), func(t *testing.T) {
+			ostrich := Ostrich{
+				WorkspaceRoot:    filepath.Join(root, "jobs"),
+				WorkspaceCleanup: test.cleanup,
+			}
+			workspace, err := ostrich.createWorkspace()
+			if err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			if filepath.Dir(workspace) != filepath.Join(root, "jobs") {
+				t.Fatalf("workspace is not in root %s", workspace)
+			}
+			ostrich.removeWorkspace(workspace, test.succeeded)
+			_, err = os.Stat(workspace)
+			if (err == nil) != test.expectExist {
+				t.Fatalf("invalid workspace existence %#v", err)
+			}
+		})
+	}
+	t.Run("invalid cleanup", func(t *testing.T) {
+		if err := ValidateWorkspaceCleanup("sometimes"); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestValidateCommitter(t *testing.T) {
+	if err := ValidateCommitter(CommitIdentity{}); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if err := ValidateCommitter(CommitIdentity{Name: "ostrich", Email: "ostrich@example.com"}); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if err := ValidateCommitter(CommitIdentity{Name: "ostrich"}); err == nil {
+		t.Fatal("not return error")
+	}
+	if err := ValidateCommitter(CommitIdentity{Email: "ostrich@example.com"}); err == nil {
+		t.Fatal("not return error")
+	}
+}
+
+func TestApplyAccumulateMode(t *testing.T) {
+	commit := Commit{
+		ID:     "75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+		Author: "miyatama",
+		OstrichFileInfos: []OstrichFileInfo{
+			{
+				Filename:          "./run.go",
+				Mode:              "100755",
+				InfoType:          OstrichFileInfoTypeNewFile,
+				OstrichMergeInfos: []OstrichMergeInfo{{afterTexts: []string{"package main"}}},
+			},
+			{
+				Filename:          "./main.go",
+				InfoType:          OstrichFileInfoTypeNewFile,
+				OstrichMergeInfos: []OstrichMergeInfo{{afterTexts: []string{"package main"}}},
+			},
+		},
+	}
+	t.Run("accumulate mode", func(t *testing.T) {
+		executor := &DummyRecordExecutor{}
+		ostrich := Ostrich{Accumulate: true, FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{}}}
+		if err := ostrich.applyCommit(commit, GitCommand{executor: executor}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		chmods := []string{}
+		for _, command := range executor.Commands {
+			if strings.HasPrefix(command, "update-index") {
+				chmods = append(chmods, command)
+			}
+		}
+		if len(chmods) != 1 || chmods[0] != "update-index --chmod=+x ./run.go" {
+			t.Fatalf("invalid commands %#v", executor.Commands)
+		}
+	})
+	t.Run("working tree of source commit", func(t *testing.T) {
+		executor := &DummyRecordExecutor{}
+		ostrich := Ostrich{FileAccessor: &DummyMapFileAccessor{Files: map[string][]string{}}}
+		if err := ostrich.applyCommit(commit, GitCommand{executor: executor}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		for _, command := range executor.Commands {
+			if strings.HasPrefix(command, "update-index") {
+				t.Fatalf("invalid commands %#v", executor.Commands)
+			}
+		}
+	})
+}
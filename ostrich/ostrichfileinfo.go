@@ -2,6 +2,10 @@ package ostrich
 
 type OstrichFileInfo struct {
 	Filename          string
+	OldFilename       string // rename or copy source
+	Mode              string // new mode.ex) 100755
+	SubmoduleCommit   string // gitlink commit.empty is removed submodule
+	NoNewlineAtEOF    bool   // new file ends with "\ No newline at end of file"
 	InfoType          OstrichFileInfoType
 	OstrichMergeInfos []OstrichMergeInfo
 }
@@ -12,4 +16,9 @@ const (
 	OstrichFileInfoTypeNewFile OstrichFileInfoType = iota
 	OstrichFileInfoTypeModFile
 	OstrichFileInfoTypeDelFile
+	OstrichFileInfoTypeRenameFile
+	OstrichFileInfoTypeCopyFile
+	OstrichFileInfoTypeBinaryFile
+	OstrichFileInfoTypeModeFile
+	OstrichFileInfoTypeSubmodule
 )
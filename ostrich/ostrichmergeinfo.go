@@ -1,11 +1,14 @@
 package ostrich
 
 type OstrichMergeInfo struct {
-	no              int
-	ostrichType     OstrichType
-	targetLine      int      // edit start line 
-	removeTexts     []string // remove or modified texts
-	afterTexts      []string // add or modify texts
+	no            int
+	ostrichType   OstrichType
+	targetLine    int      // edit start line of new file
+	sourceLine    int      // edit start line of old file
+	removeTexts   []string // remove or modified texts
+	afterTexts    []string // add or modify texts
+	leadingTexts  []string // context texts before edit
+	trailingTexts []string // context texts after edit
 }
 
 type OstrichType int
@@ -0,0 +1,202 @@
+package ostrich
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// first line of commit in git show and git format-patch output
+var (
+	showDefaultHeading = regexp.MustCompile(`^commit [0-9a-f]{7,40}`)
+	formatPatchHeading = regexp.MustCompile(`^From [0-9a-f]{40} `)
+	patchSubjectPrefix = regexp.MustCompile(`^\[[^\]]*\]\s*`)
+)
+
+const (
+	showDefaultDateLayout = "Mon Jan 2 15:04:05 2006 -0700"
+	formatPatchDateLayout = "Mon, 2 Jan 2006 15:04:05 -0700"
+)
+
+// ApplyPatch is apply ostrich comments of patch to files in dir without clone, commit and push.
+// files in dir are already patched.ex) working tree of the commit
+// patch is git show, git format-patch or git diff output.
+func (o *Ostrich) ApplyPatch(dir string, patchTexts []string) error {
+	o.FileAccessor.SetDir(dir)
+	if err := o.loadConfig(); err != nil {
+		return err
+	}
+	commits, err := o.parsePatches(patchTexts)
+	if err != nil {
+		return err
+	}
+
+	// index of git is not changed
+	git := GitCommand{
+		executor: &SkipCommandExecutor{},
+	}
+	for _, commit := range commits {
+		o.outputDebug(fmt.Sprintf("apply patch: %s", commit.ID))
+		if err := o.applyCommit(commit, git); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePatches is return commits in patch texts.
+func (o *Ostrich) parsePatches(texts []string) ([]Commit, error) {
+	isHeading := func(text string) bool {
+		return strings.HasPrefix(text, showFieldPrefix+"commit ") ||
+			showDefaultHeading.MatchString(text) ||
+			formatPatchHeading.MatchString(text)
+	}
+	blocks := [][]string{}
+	head := 0
+	for i, text := range texts {
+		if i > head && isHeading(text) {
+			blocks = append(blocks, texts[head:i])
+			head = i
+		}
+	}
+	blocks = append(blocks, texts[head:])
+
+	result := []Commit{}
+	for _, block := range blocks {
+		commit, err := o.parsePatch(block)
+		if err != nil {
+			return []Commit{}, err
+		}
+		result = append(result, commit)
+	}
+	return result, nil
+}
+
+// parsePatch is return commit of one patch.patch without header is commit at now.
+func (o *Ostrich) parsePatch(texts []string) (Commit, error) {
+	if len(texts) <= 0 {
+		return Commit{}, errors.New("patch is empty")
+	}
+	if strings.HasPrefix(texts[0], showFieldPrefix) {
+		return o.parseCommit(texts)
+	}
+	diffStart := -1
+	for i, text := range texts {
+		if strings.HasPrefix(text, "diff --git ") {
+			diffStart = i
+			break
+		}
+	}
+	if diffStart < 0 {
+		return Commit{}, errors.New("can not detect diff heading")
+	}
+
+	commit := Commit{
+		CommitDate: time.Now(),
+	}
+	var err error
+	if showDefaultHeading.MatchString(texts[0]) {
+		commit, err = o.parseShowDefaultHeader(texts[:diffStart])
+	} else if formatPatchHeading.MatchString(texts[0]) {
+		commit, err = o.parseFormatPatchHeader(texts[:diffStart])
+	}
+	if err != nil {
+		return Commit{}, err
+	}
+	commit.CommitterDate = commit.CommitDate
+	commit.OstrichFileInfos, err = o.parseOstrichFiles(texts[diffStart:])
+	if err != nil {
+		return Commit{}, err
+	}
+	return commit, nil
+}
+
+// parseShowDefaultHeader is parse header of git show without format.
+// format: commit ID, Author: Name <email>, Date: Tue Mar 31 13:35:14 2020 +0900 and indented message
+func (o *Ostrich) parseShowDefaultHeader(texts []string) (Commit, error) {
+	commit := Commit{
+		ID: strings.Fields(texts[0])[1],
+	}
+	messageLines := []string{}
+	for _, text := range texts[1:] {
+		if strings.HasPrefix(text, "    ") {
+			messageLines = append(messageLines, text)
+			continue
+		}
+		if strings.HasPrefix(text, "Author:") {
+			commit.Author, commit.AuthorEmail = parseIdentity(strings.TrimSpace(strings.TrimPrefix(text, "Author:")))
+		}
+		if strings.HasPrefix(text, "Date:") {
+			dateText := strings.TrimSpace(strings.TrimPrefix(text, "Date:"))
+			date, err := time.Parse(showDefaultDateLayout, dateText)
+			if err != nil {
+				return Commit{}, fmt.Errorf("can not detect author-date %s", dateText)
+			}
+			commit.CommitDate = date
+		}
+	}
+	commit.Committer = commit.Author
+	commit.CommitterEmail = commit.AuthorEmail
+	commit.Message, commit.Subject = joinMessageLines(messageLines)
+	return commit, nil
+}
+
+// parseFormatPatchHeader is parse mail header and body of git format-patch.
+// format: From ID date, From: Name <email>, Date: Tue, 31 Mar 2020 13:35:14 +0900, Subject: [PATCH] subject
+func (o *Ostrich) parseFormatPatchHeader(texts []string) (Commit, error) {
+	commit := Commit{
+		ID: strings.Fields(texts[0])[1],
+	}
+
+	// header is until empty line.continued header line starts with space
+	headers := map[string]string{}
+	name := ""
+	bodyStart := len(texts)
+	for i, text := range texts[1:] {
+		if len(text) <= 0 {
+			bodyStart = i + 2
+			break
+		}
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			headers[name] = headers[name] + text
+			continue
+		}
+		terms := strings.SplitN(text, ":", 2)
+		if len(terms) < 2 {
+			continue
+		}
+		name = terms[0]
+		headers[name] = strings.TrimSpace(terms[1])
+	}
+	decoder := new(mime.WordDecoder)
+	decode := func(text string) string {
+		decoded, err := decoder.DecodeHeader(text)
+		if err != nil {
+			return text
+		}
+		return decoded
+	}
+
+	commit.Author, commit.AuthorEmail = parseIdentity(decode(headers["From"]))
+	commit.Committer = commit.Author
+	commit.CommitterEmail = commit.AuthorEmail
+	date, err := time.Parse(formatPatchDateLayout, headers["Date"])
+	if err != nil {
+		return Commit{}, fmt.Errorf("can not detect author-date %s", headers["Date"])
+	}
+	commit.CommitDate = date
+
+	// body is until "---" of diffstat
+	messageLines := []string{patchSubjectPrefix.ReplaceAllString(decode(headers["Subject"]), "")}
+	for _, text := range texts[bodyStart:] {
+		if text == "---" {
+			break
+		}
+		messageLines = append(messageLines, text)
+	}
+	commit.Message, commit.Subject = joinMessageLines(messageLines)
+	return commit, nil
+}
@@ -0,0 +1,151 @@
+package ostrich
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePatches(t *testing.T) {
+	ostrich := Ostrich{
+		FileAccessor: &DummyFileAcccessor{},
+	}
+	t.Run("git format-patch", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/format_patch_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commits, err := ostrich.parsePatches(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commits) != 2 {
+			t.Fatalf("invalid commits length %d", len(commits))
+		}
+		commit := commits[0]
+		if commit.ID != "75f6622e3827fc3a1ae74fc9c18590b5214adcd1" {
+			t.Fatalf("invalid commit id %s", commit.ID)
+		}
+		if commit.Author != "山田 太郎" || commit.AuthorEmail != "Taro.Yamada@example.com" {
+			t.Fatalf("invalid author %s <%s>", commit.Author, commit.AuthorEmail)
+		}
+		if commit.CommitDate.Format("2006-01-02 15:04:05") != "2020-03-31 13:35:14" {
+			t.Fatalf("invalid commit date %s", commit.CommitDate)
+		}
+		if commit.Subject != "mod print message for review" {
+			t.Fatalf("invalid subject %s", commit.Subject)
+		}
+		if commit.Message != "mod print message for review, fix ISSUE-123" {
+			t.Fatalf("invalid message %s", commit.Message)
+		}
+		if len(commit.OstrichFileInfos) != 1 || len(commit.OstrichFileInfos[0].OstrichMergeInfos) != 1 {
+			t.Fatalf("invalid ostrich file infos %#v", commit.OstrichFileInfos)
+		}
+		if commits[1].CommitDate.Format("2006-01-02") != "2020-04-01" {
+			t.Fatalf("invalid commit date %s", commits[1].CommitDate)
+		}
+		if commits[1].OstrichFileInfos[0].InfoType != OstrichFileInfoTypeDelFile {
+			t.Fatalf("invalid info type %d", commits[1].OstrichFileInfos[0].InfoType)
+		}
+	})
+	t.Run("git show", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/show_default_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commits, err := ostrich.parsePatches(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commits) != 1 {
+			t.Fatalf("invalid commits length %d", len(commits))
+		}
+		commit := commits[0]
+		if commit.Author != "Taro Yamada" || commit.AuthorEmail != "Taro.Yamada@example.com" {
+			t.Fatalf("invalid author %s <%s>", commit.Author, commit.AuthorEmail)
+		}
+		if commit.CommitDate.Format("2006-01-02 15:04:05") != "2020-03-31 13:35:14" {
+			t.Fatalf("invalid commit date %s", commit.CommitDate)
+		}
+		if commit.Message != "mod print message, fix ISSUE-123" {
+			t.Fatalf("invalid message %s", commit.Message)
+		}
+		if commit.OstrichFileInfos[0].Filename != "./main.go" {
+			t.Fatalf("invalid filename %s", commit.OstrichFileInfos[0].Filename)
+		}
+	})
+	t.Run("git show with format", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/mod_file_commit_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		commits, err := ostrich.parsePatches(strings.Split(string(b), "\n"))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commits) != 1 || commits[0].ID != "75f6622e3827fc3a1ae74fc9c18590b5214adcd1" {
+			t.Fatalf("invalid commits %#v", commits)
+		}
+	})
+	t.Run("diff only", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../testdata/show_default_text.txt")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		texts := strings.Split(string(b), "\n")
+		commits, err := ostrich.parsePatches(texts[7:])
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(commits) != 1 || commits[0].CommitDate.IsZero() {
+			t.Fatalf("invalid commits %#v", commits)
+		}
+	})
+	t.Run("no diff", func(t *testing.T) {
+		if _, err := ostrich.parsePatches([]string{"hello"}); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestApplyPatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	mainText := "package main\n\nimport (\n       \"fmt\"\n)\n\nfunc main() {\n       fmt.Println(\"hello world\")\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(mainText), 0644); err != nil {
+		t.Fatalf("can not write test file.%#v", err)
+	}
+	b, err := ioutil.ReadFile("../testdata/show_default_text.txt")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+
+	ostrich := Ostrich{
+		FileAccessor: &FileAccesser{},
+	}
+	current, _ := os.Getwd()
+	if err := ostrich.ApplyPatch(dir, strings.Split(string(b), "\n")); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if wd, _ := os.Getwd(); wd != current {
+		t.Fatalf("working directory is changed %s", wd)
+	}
+	result, err := ioutil.ReadFile(filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatalf("can not read result.%#v", err)
+	}
+	expect := "package main\n\nimport (\n       \"fmt\"\n)\n\nfunc main() {\n" +
+		"       // 2020/03/31 MOD Taro Yamada START\n" +
+		"       // fmt.Println(\"hello\")\n" +
+		"       fmt.Println(\"hello world\")\n" +
+		"       // 2020/03/31 MOD Taro Yamada END\n" +
+		"}\n"
+	if string(result) != expect {
+		t.Fatalf("invalid result.expect %q, result %q", expect, string(result))
+	}
+}
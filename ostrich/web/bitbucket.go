@@ -0,0 +1,87 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// header of Bitbucket Server webhook
+const (
+	BitbucketEventHeader     = "X-Event-Key"
+	BitbucketSignatureHeader = "X-Hub-Signature"
+)
+
+// bitbucketRefsChangedPayload is used fields of Bitbucket Server repo:refs_changed event.
+type bitbucketRefsChangedPayload struct {
+	Repository struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"repository"`
+	Changes []struct {
+		RefID    string `json:"refId"`
+		FromHash string `json:"fromHash"`
+		ToHash   string `json:"toHash"`
+		Type     string `json:"type"`
+	} `json:"changes"`
+}
+
+// BitbucketReceiver is Bitbucket Server push webhook.
+// payload has no clone url, so CloneURL is clone url with {PROJECT} and {REPOSITORY}.
+// ex) https://bitbucket.example.com/scm/{PROJECT}/{REPOSITORY}.git
+type BitbucketReceiver struct {
+	CloneURL string
+}
+
+// Verify is verify X-Hub-Signature.format is sha256=hex.
+func (r BitbucketReceiver) Verify(secret string, header http.Header, body []byte) error {
+	signature := header.Get(BitbucketSignatureHeader)
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("invalid bitbucket signature")
+	}
+	return verifyHMACSHA256(secret, body, strings.TrimPrefix(signature, "sha256="))
+}
+
+// Parse is return pushes of changed refs.
+// payload has no commit list, so updated ref is commit range.commits of added ref are unknown, so it is truncated.
+func (r BitbucketReceiver) Parse(header http.Header, body []byte) ([]PushEvent, error) {
+	event := header.Get(BitbucketEventHeader)
+	if event != "repo:refs_changed" {
+		return []PushEvent{}, fmt.Errorf("%w.%s", ErrEventIgnored, event)
+	}
+	if len(r.CloneURL) <= 0 {
+		return []PushEvent{}, errors.New("bitbucket clone url is not configured")
+	}
+	payload := bitbucketRefsChangedPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return []PushEvent{}, err
+	}
+	repository := strings.NewReplacer(
+		"{PROJECT}", strings.ToLower(payload.Repository.Project.Key),
+		"{REPOSITORY}", payload.Repository.Slug,
+	).Replace(r.CloneURL)
+
+	result := []PushEvent{}
+	for _, change := range payload.Changes {
+		push := PushEvent{
+			Repository: repository,
+			Ref:        change.RefID,
+			CommitIDs:  []string{},
+			Deleted:    change.Type == "DELETE",
+		}
+		switch {
+		case push.Deleted:
+		case change.Type == "ADD" || change.FromHash == zeroCommitID:
+			push.CommitIDs = append(push.CommitIDs, change.ToHash)
+			push.Truncated = true
+		default:
+			push.CommitRange = fmt.Sprintf("%s..%s", change.FromHash, change.ToHash)
+		}
+		result = append(result, push)
+	}
+	return result, nil
+}
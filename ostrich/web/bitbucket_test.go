@@ -0,0 +1,66 @@
+package web
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestBitbucketReceiver(t *testing.T) {
+	b, err := ioutil.ReadFile("../../testdata/bitbucket_refs_changed_event.json")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	receiver := BitbucketReceiver{CloneURL: "https://bitbucket.example.com/scm/{PROJECT}/{REPOSITORY}.git"}
+	t.Run("verify signature", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(BitbucketSignatureHeader, "sha256="+signHex("secret", b))
+		if err := receiver.Verify("secret", header, b); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		header.Set(BitbucketSignatureHeader, signHex("secret", b))
+		if err := receiver.Verify("secret", header, b); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("refs changed", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(BitbucketEventHeader, "repo:refs_changed")
+		pushes, err := receiver.Parse(header, b)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(pushes) != 3 {
+			t.Fatalf("invalid pushes %#v", pushes)
+		}
+		for _, push := range pushes {
+			if push.Repository != "https://bitbucket.example.com/scm/miya/sample.git" {
+				t.Fatalf("invalid repository %s", push.Repository)
+			}
+		}
+		if pushes[0].CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" {
+			t.Fatalf("invalid commit range %s", pushes[0].CommitRange)
+		}
+		if pushes[1].Branch() != "release/1.0" || len(pushes[1].CommitIDs) != 1 || len(pushes[1].CommitRange) > 0 || !pushes[1].Truncated {
+			t.Fatalf("invalid added branch push %#v", pushes[1])
+		}
+		if pushes[0].Truncated || !pushes[2].Deleted {
+			t.Fatalf("invalid deleted branch push %#v", pushes[2])
+		}
+	})
+	t.Run("ping", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(BitbucketEventHeader, "diagnostics:ping")
+		if _, err := receiver.Parse(header, b); !errors.Is(err, ErrEventIgnored) {
+			t.Fatalf("not return ignored error %#v", err)
+		}
+	})
+	t.Run("no clone url", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(BitbucketEventHeader, "repo:refs_changed")
+		if _, err := (BitbucketReceiver{}).Parse(header, b); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
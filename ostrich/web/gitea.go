@@ -0,0 +1,71 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// header of Gitea and Gogs webhook
+const (
+	GiteaEventHeader     = "X-Gitea-Event"
+	GiteaSignatureHeader = "X-Gitea-Signature"
+	GogsEventHeader      = "X-Gogs-Event"
+	GogsSignatureHeader  = "X-Gogs-Signature"
+)
+
+// giteaPushPayload is used fields of Gitea and Gogs push event.
+type giteaPushPayload struct {
+	Ref     string `json:"ref"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Commits []struct {
+		ID string `json:"id"`
+	} `json:"commits"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+	} `json:"repository"`
+}
+
+// GiteaReceiver is Gitea and Gogs push webhook.
+type GiteaReceiver struct{}
+
+// Verify is verify X-Gitea-Signature or X-Gogs-Signature.format is hex without prefix.
+func (r GiteaReceiver) Verify(secret string, header http.Header, body []byte) error {
+	signature := header.Get(GiteaSignatureHeader)
+	if len(signature) <= 0 {
+		signature = header.Get(GogsSignatureHeader)
+	}
+	return verifyHMACSHA256(secret, body, signature)
+}
+
+// Parse is return push of push event.
+// updated branch is commit range.commits of created branch are new to old in payload, so they are reversed.
+func (r GiteaReceiver) Parse(header http.Header, body []byte) ([]PushEvent, error) {
+	event := header.Get(GiteaEventHeader)
+	if len(event) <= 0 {
+		event = header.Get(GogsEventHeader)
+	}
+	if event != "push" {
+		return []PushEvent{}, fmt.Errorf("%w.%s", ErrEventIgnored, event)
+	}
+	payload := giteaPushPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return []PushEvent{}, err
+	}
+	if len(payload.Repository.CloneURL) <= 0 {
+		return []PushEvent{}, errors.New("repository clone_url is not found")
+	}
+	push := PushEvent{
+		Repository: payload.Repository.CloneURL,
+		Ref:        payload.Ref,
+		Deleted:    payload.After == zeroCommitID,
+	}
+	commitIDs := []string{}
+	for i := len(payload.Commits) - 1; i >= 0; i-- {
+		commitIDs = append(commitIDs, payload.Commits[i].ID)
+	}
+	push.setCommits(payload.Before, payload.After, commitIDs)
+	return []PushEvent{push}, nil
+}
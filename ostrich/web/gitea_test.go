@@ -0,0 +1,79 @@
+package web
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGiteaReceiver(t *testing.T) {
+	b, err := ioutil.ReadFile("../../testdata/gitea_push_event.json")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	receiver := GiteaReceiver{}
+	t.Run("verify signature", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GiteaSignatureHeader, signHex("secret", b))
+		if err := receiver.Verify("secret", header, b); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		gogsHeader := http.Header{}
+		gogsHeader.Set(GogsSignatureHeader, signHex("secret", b))
+		if err := receiver.Verify("secret", gogsHeader, b); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		header.Set(GiteaSignatureHeader, signHex("other", b))
+		if err := receiver.Verify("secret", header, b); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("push event", func(t *testing.T) {
+		for _, eventHeader := range []string{GiteaEventHeader, GogsEventHeader} {
+			header := http.Header{}
+			header.Set(eventHeader, "push")
+			pushes, err := receiver.Parse(header, b)
+			if err != nil {
+				t.Fatalf("return error %#v", err)
+			}
+			push := pushes[0]
+			if push.Repository != "https://gitea.example.com/miyatama/sample.git" || push.Branch() != "master" {
+				t.Fatalf("invalid push %#v", push)
+			}
+			if push.CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" || len(push.CommitIDs) > 0 {
+				t.Fatalf("invalid commits %#v", push)
+			}
+		}
+	})
+	t.Run("created branch", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GiteaEventHeader, "push")
+		created := strings.Replace(string(b), "9049f1265b7d61be4a8904a9a27120d2064dab3b", zeroCommitID, 1)
+		pushes, err := receiver.Parse(header, []byte(created))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		// commits of payload are new to old
+		expectCommitIDs := []string{
+			"75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+			"0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+		}
+		if len(pushes[0].CommitIDs) != len(expectCommitIDs) || len(pushes[0].CommitRange) > 0 {
+			t.Fatalf("invalid commits %#v", pushes[0])
+		}
+		for i, commitID := range expectCommitIDs {
+			if pushes[0].CommitIDs[i] != commitID {
+				t.Fatalf("invalid commit id %d.expect %s, result %s", i, commitID, pushes[0].CommitIDs[i])
+			}
+		}
+	})
+	t.Run("other event", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GiteaEventHeader, "create")
+		if _, err := receiver.Parse(header, b); !errors.Is(err, ErrEventIgnored) {
+			t.Fatalf("not return ignored error %#v", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// header of GitHub webhook
+const (
+	GitHubEventHeader     = "X-GitHub-Event"
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+// gitHubPushPayload is used fields of GitHub push event.
+type gitHubPushPayload struct {
+	Ref     string `json:"ref"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+	Commits []struct {
+		ID string `json:"id"`
+	} `json:"commits"`
+	Repository struct {
+		CloneURL string `json:"clone_url"`
+	} `json:"repository"`
+}
+
+// gitHubCreatedBranchCommitLimit is max commits of created branch in GitHub push event.
+const gitHubCreatedBranchCommitLimit = 20
+
+// GitHubReceiver is GitHub push webhook.
+type GitHubReceiver struct{}
+
+// Verify is verify X-Hub-Signature-256.
+func (r GitHubReceiver) Verify(secret string, header http.Header, body []byte) error {
+	return VerifyGitHubSignature(secret, body, header.Get(GitHubSignatureHeader))
+}
+
+// Parse is return push of push event.
+func (r GitHubReceiver) Parse(header http.Header, body []byte) ([]PushEvent, error) {
+	event := header.Get(GitHubEventHeader)
+	if event != "push" {
+		return []PushEvent{}, fmt.Errorf("%w.%s", ErrEventIgnored, event)
+	}
+	push, err := ParseGitHubPushEvent(body)
+	if err != nil {
+		return []PushEvent{}, err
+	}
+	return []PushEvent{push}, nil
+}
+
+// VerifyGitHubSignature is return error when signature is not HMAC-SHA256 of body by secret.
+// format of signature: sha256=hex
+func VerifyGitHubSignature(secret string, body []byte, signature string) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("invalid github signature")
+	}
+	return verifyHMACSHA256(secret, body, strings.TrimPrefix(signature, "sha256="))
+}
+
+// ParseGitHubPushEvent is return push of GitHub push event payload.
+// commits of payload has merged commits and is truncated in large push, so updated branch is commit range.
+// created branch with commits of limit may have more commits, so it is truncated.
+func ParseGitHubPushEvent(body []byte) (PushEvent, error) {
+	payload := gitHubPushPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PushEvent{}, err
+	}
+	if len(payload.Repository.CloneURL) <= 0 {
+		return PushEvent{}, errors.New("repository clone_url is not found")
+	}
+	event := PushEvent{
+		Repository: payload.Repository.CloneURL,
+		Ref:        payload.Ref,
+		Deleted:    payload.Deleted,
+	}
+	commitIDs := []string{}
+	for _, commit := range payload.Commits {
+		commitIDs = append(commitIDs, commit.ID)
+	}
+	event.setCommits(payload.Before, payload.After, commitIDs)
+	event.Truncated = len(event.CommitIDs) >= gitHubCreatedBranchCommitLimit
+	return event, nil
+}
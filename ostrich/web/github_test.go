@@ -0,0 +1,165 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// signHex is return hex HMAC-SHA256 of body.
+func signHex(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signGitHub(secret string, body []byte) string {
+	return "sha256=" + signHex(secret, body)
+}
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/master"}`)
+	t.Run("valid signature", func(t *testing.T) {
+		if err := VerifyGitHubSignature("secret", body, signGitHub("secret", body)); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+	})
+	t.Run("other secret", func(t *testing.T) {
+		if err := VerifyGitHubSignature("secret", body, signGitHub("other", body)); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("invalid format", func(t *testing.T) {
+		for _, signature := range []string{"", "sha1=abcd", "sha256=xyz"} {
+			if err := VerifyGitHubSignature("secret", body, signature); err == nil {
+				t.Fatalf("not return error %s", signature)
+			}
+		}
+	})
+	t.Run("no secret", func(t *testing.T) {
+		if err := VerifyGitHubSignature("", body, signGitHub("", body)); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestParseGitHubPushEvent(t *testing.T) {
+	t.Run("push event", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../../testdata/github_push_event.json")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		event, err := ParseGitHubPushEvent(b)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if event.Repository != "https://github.com/miyatama/sample.git" {
+			t.Fatalf("invalid repository %s", event.Repository)
+		}
+		if event.Branch() != "master" {
+			t.Fatalf("invalid branch %s", event.Branch())
+		}
+		if event.CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" || len(event.CommitIDs) > 0 {
+			t.Fatalf("invalid commits %#v", event)
+		}
+	})
+	t.Run("push with merge commit", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../../testdata/github_push_merge_event.json")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		event, err := ParseGitHubPushEvent(b)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		// merged commits are chosen by rev-list
+		if event.CommitRange != "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c..c3b1f5d2a8e94f6b7d0e1a2b3c4d5e6f7a8b9c0d" || len(event.CommitIDs) > 0 {
+			t.Fatalf("invalid commits %#v", event)
+		}
+	})
+	t.Run("created branch", func(t *testing.T) {
+		b, err := ioutil.ReadFile("../../testdata/github_push_event.json")
+		if err != nil {
+			t.Fatal("can not read test data")
+		}
+		created := strings.Replace(string(b), "9049f1265b7d61be4a8904a9a27120d2064dab3b", zeroCommitID, 1)
+		event, err := ParseGitHubPushEvent([]byte(created))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		expectCommitIDs := []string{
+			"75f6622e3827fc3a1ae74fc9c18590b5214adcd1",
+			"0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+		}
+		if len(event.CommitIDs) != len(expectCommitIDs) || len(event.CommitRange) > 0 {
+			t.Fatalf("invalid commits %#v", event)
+		}
+		for i, commitID := range expectCommitIDs {
+			if event.CommitIDs[i] != commitID {
+				t.Fatalf("invalid commit id %d.expect %s, result %s", i, commitID, event.CommitIDs[i])
+			}
+		}
+		if event.Truncated {
+			t.Fatalf("invalid truncated %#v", event)
+		}
+	})
+	t.Run("created branch with commits of limit", func(t *testing.T) {
+		commits := []string{}
+		for i := 0; i < gitHubCreatedBranchCommitLimit; i++ {
+			commits = append(commits, fmt.Sprintf(`{"id": "%040d"}`, i+1))
+		}
+		body := fmt.Sprintf(
+			`{"ref": "refs/heads/feature", "before": "%s", "after": "%040d", "commits": [%s], "repository": {"clone_url": "https://github.com/miyatama/sample.git"}}`,
+			zeroCommitID,
+			gitHubCreatedBranchCommitLimit,
+			strings.Join(commits, ","))
+		event, err := ParseGitHubPushEvent([]byte(body))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(event.CommitIDs) != gitHubCreatedBranchCommitLimit || !event.Truncated {
+			t.Fatalf("invalid event %#v", event)
+		}
+		if _, err := (WebhookConfig{OstrichBranch: "ostrich-{BRANCH}"}).NewOstrichWebRequest(event); !errors.Is(err, ErrPushSkipped) {
+			t.Fatalf("invalid return %#v", err)
+		}
+	})
+	t.Run("invalid json", func(t *testing.T) {
+		if _, err := ParseGitHubPushEvent([]byte("{")); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("no repository", func(t *testing.T) {
+		if _, err := ParseGitHubPushEvent([]byte(`{"ref":"refs/heads/master"}`)); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+}
+
+func TestGitHubReceiver(t *testing.T) {
+	b, err := ioutil.ReadFile("../../testdata/github_push_event.json")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	receiver := GitHubReceiver{}
+	header := http.Header{}
+	header.Set(GitHubSignatureHeader, signGitHub("secret", b))
+	if err := receiver.Verify("secret", header, b); err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	header.Set(GitHubEventHeader, "push")
+	pushes, err := receiver.Parse(header, b)
+	if err != nil || len(pushes) != 1 {
+		t.Fatalf("invalid return %#v, %#v", pushes, err)
+	}
+	header.Set(GitHubEventHeader, "ping")
+	if _, err := receiver.Parse(header, b); !errors.Is(err, ErrEventIgnored) {
+		t.Fatalf("not return ignored error %#v", err)
+	}
+}
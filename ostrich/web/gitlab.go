@@ -0,0 +1,72 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// header of GitLab webhook
+const (
+	GitLabEventHeader = "X-Gitlab-Event"
+	GitLabTokenHeader = "X-Gitlab-Token"
+)
+
+// gitLabPushPayload is used fields of GitLab push hook.
+type gitLabPushPayload struct {
+	ObjectKind        string `json:"object_kind"`
+	Ref               string `json:"ref"`
+	Before            string `json:"before"`
+	After             string `json:"after"`
+	TotalCommitsCount int    `json:"total_commits_count"`
+	Commits           []struct {
+		ID string `json:"id"`
+	} `json:"commits"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+	} `json:"project"`
+}
+
+// GitLabReceiver is GitLab push hook.
+type GitLabReceiver struct{}
+
+// Verify is compare X-Gitlab-Token with secret.GitLab sends secret token as-is.
+func (r GitLabReceiver) Verify(secret string, header http.Header, body []byte) error {
+	if len(secret) <= 0 {
+		return errors.New("webhook secret is not configured")
+	}
+	if subtle.ConstantTimeCompare([]byte(header.Get(GitLabTokenHeader)), []byte(secret)) != 1 {
+		return errors.New("gitlab token is not matched")
+	}
+	return nil
+}
+
+// Parse is return push of push hook.
+// commits of payload are up to 20, so updated branch is commit range.
+func (r GitLabReceiver) Parse(header http.Header, body []byte) ([]PushEvent, error) {
+	event := header.Get(GitLabEventHeader)
+	if event != "Push Hook" {
+		return []PushEvent{}, fmt.Errorf("%w.%s", ErrEventIgnored, event)
+	}
+	payload := gitLabPushPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return []PushEvent{}, err
+	}
+	if len(payload.Project.GitHTTPURL) <= 0 {
+		return []PushEvent{}, errors.New("project git_http_url is not found")
+	}
+	push := PushEvent{
+		Repository: payload.Project.GitHTTPURL,
+		Ref:        payload.Ref,
+		Deleted:    payload.After == zeroCommitID,
+	}
+	commitIDs := []string{}
+	for _, commit := range payload.Commits {
+		commitIDs = append(commitIDs, commit.ID)
+	}
+	push.setCommits(payload.Before, payload.After, commitIDs)
+	push.Truncated = len(push.CommitIDs) > 0 && payload.TotalCommitsCount > len(payload.Commits)
+	return []PushEvent{push}, nil
+}
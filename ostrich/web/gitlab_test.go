@@ -0,0 +1,82 @@
+package web
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGitLabReceiver(t *testing.T) {
+	b, err := ioutil.ReadFile("../../testdata/gitlab_push_event.json")
+	if err != nil {
+		t.Fatal("can not read test data")
+	}
+	receiver := GitLabReceiver{}
+	t.Run("verify token", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GitLabTokenHeader, "secret")
+		if err := receiver.Verify("secret", header, b); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		header.Set(GitLabTokenHeader, "other")
+		if err := receiver.Verify("secret", header, b); err == nil {
+			t.Fatal("not return error")
+		}
+		if err := receiver.Verify("", http.Header{}, b); err == nil {
+			t.Fatal("not return error")
+		}
+	})
+	t.Run("push hook", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GitLabEventHeader, "Push Hook")
+		pushes, err := receiver.Parse(header, b)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(pushes) != 1 {
+			t.Fatalf("invalid pushes %#v", pushes)
+		}
+		push := pushes[0]
+		if push.Repository != "https://gitlab.example.com/miyatama/sample.git" || push.Branch() != "master" || push.Deleted {
+			t.Fatalf("invalid push %#v", push)
+		}
+		if push.CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" || len(push.CommitIDs) > 0 {
+			t.Fatalf("invalid commits %#v", push)
+		}
+	})
+	t.Run("truncated commits", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GitLabEventHeader, "Push Hook")
+		truncated := strings.Replace(string(b), `"total_commits_count": 2`, `"total_commits_count": 25`, 1)
+		pushes, err := receiver.Parse(header, []byte(truncated))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if pushes[0].CommitRange != "9049f1265b7d61be4a8904a9a27120d2064dab3b..0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c" || pushes[0].Truncated {
+			t.Fatalf("invalid commits %#v", pushes[0])
+		}
+
+		// created branch has no before
+		created := strings.Replace(truncated, "9049f1265b7d61be4a8904a9a27120d2064dab3b", zeroCommitID, 1)
+		pushes, err = receiver.Parse(header, []byte(created))
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(pushes[0].CommitIDs) != 2 || !pushes[0].Truncated {
+			t.Fatalf("invalid commits %#v", pushes[0])
+		}
+		config := WebhookConfig{OstrichBranch: "ostrich-{BRANCH}"}
+		if _, err := config.NewOstrichWebRequest(pushes[0]); !errors.Is(err, ErrPushSkipped) {
+			t.Fatalf("not return skipped error %#v", err)
+		}
+	})
+	t.Run("other hook", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(GitLabEventHeader, "Tag Push Hook")
+		if _, err := receiver.Parse(header, b); !errors.Is(err, ErrEventIgnored) {
+			t.Fatalf("not return ignored error %#v", err)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package web
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrJobNotFound is error when job id is not in store.
+var ErrJobNotFound = errors.New("job is not found")
+
+// JobState is state of ostrich job.
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+)
+
+// Job is ostrich request and its result.
+type Job struct {
+	ID              string            `json:"id"`
+	State           JobState          `json:"state"`
+	Request         OstrichWebRequest `json:"request"`
+	Attempts        int               `json:"attempts"`
+	Error           string            `json:"error,omitempty"`
+	OstrichCommitID string            `json:"ostrichCommitId,omitempty"`
+	Diffs           []OstrichWebDiff  `json:"diffs,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+// Finished is return true when job is succeeded or failed.
+func (j Job) Finished() bool {
+	return j.State == JobStateSucceeded || j.State == JobStateFailed
+}
+
+// jobBucket is bucket of jobs.key is big endian sequence, so jobs are sorted by created order.
+var jobBucket = []byte("jobs")
+
+// JobStore is jobs in BoltDB file.jobs are kept after restart.
+type JobStore struct {
+	db *bolt.DB
+}
+
+// OpenJobStore is open or create BoltDB file of jobs.
+func OpenJobStore(path string) (*JobStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("can not open job store %s.%s", path, err.Error())
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &JobStore{db: db}, nil
+}
+
+func (s *JobStore) Close() error {
+	return s.db.Close()
+}
+
+// Create is add queued job of request.
+func (s *JobStore) Create(request OstrichWebRequest) (Job, error) {
+	job := Job{}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobBucket)
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		job = Job{
+			ID:        strconv.FormatUint(sequence, 10),
+			State:     JobStateQueued,
+			Request:   request,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return putJob(bucket, sequence, job)
+	})
+	return job, err
+}
+
+// Get is return job of id.
+func (s *JobStore) Get(id string) (Job, error) {
+	job := Job{}
+	sequence, err := jobSequence(id)
+	if err != nil {
+		return job, err
+	}
+	err = s.db.View(func(tx *bolt.Tx) error {
+		job, err = getJob(tx.Bucket(jobBucket), sequence, id)
+		return err
+	})
+	return job, err
+}
+
+// Update is change job of id by update and return changed job.
+func (s *JobStore) Update(id string, update func(job *Job)) (Job, error) {
+	job := Job{}
+	sequence, err := jobSequence(id)
+	if err != nil {
+		return job, err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobBucket)
+		job, err = getJob(bucket, sequence, id)
+		if err != nil {
+			return err
+		}
+		update(&job)
+		job.UpdatedAt = time.Now()
+		return putJob(bucket, sequence, job)
+	})
+	return job, err
+}
+
+// Delete is remove job of id.
+func (s *JobStore) Delete(id string) error {
+	sequence, err := jobSequence(id)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobBucket).Delete(jobKey(sequence))
+	})
+}
+
+// List is return jobs from newest.limit 0 is all jobs, and empty state is all states.
+func (s *JobStore) List(state JobState, limit int) ([]Job, error) {
+	result := []Job{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(jobBucket).Cursor()
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			if limit > 0 && len(result) >= limit {
+				break
+			}
+			job := Job{}
+			if err := json.Unmarshal(value, &job); err != nil {
+				return err
+			}
+			if len(state) > 0 && job.State != state {
+				continue
+			}
+			result = append(result, job)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Unfinished is return queued and running jobs from oldest.
+// running job is interrupted by stop of server, so it is processed again.
+func (s *JobStore) Unfinished() ([]Job, error) {
+	result := []Job{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobBucket).ForEach(func(key []byte, value []byte) error {
+			job := Job{}
+			if err := json.Unmarshal(value, &job); err != nil {
+				return err
+			}
+			if !job.Finished() {
+				result = append(result, job)
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+func jobSequence(id string) (uint64, error) {
+	sequence, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w.%s", ErrJobNotFound, id)
+	}
+	return sequence, nil
+}
+
+func jobKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
+
+func getJob(bucket *bolt.Bucket, sequence uint64, id string) (Job, error) {
+	job := Job{}
+	value := bucket.Get(jobKey(sequence))
+	if value == nil {
+		return job, fmt.Errorf("%w.%s", ErrJobNotFound, id)
+	}
+	err := json.Unmarshal(value, &job)
+	return job, err
+}
+
+func putJob(bucket *bolt.Bucket, sequence uint64, job Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(jobKey(sequence), value)
+}
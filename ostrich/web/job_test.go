@@ -0,0 +1,107 @@
+package web
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJobStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ostrich")
+	if err != nil {
+		t.Fatalf("can not create temp dir.%#v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jobs.db")
+	store, err := OpenJobStore(path)
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+
+	first, err := store.Create(OstrichWebRequest{Repository: "https://github.com/x/y.git", OstrichBranch: "ostrich"})
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	second, err := store.Create(OstrichWebRequest{Repository: "https://github.com/x/z.git", OstrichBranch: "ostrich"})
+	if err != nil {
+		t.Fatalf("return error %#v", err)
+	}
+	if first.ID == second.ID || first.State != JobStateQueued {
+		t.Fatalf("invalid jobs %#v, %#v", first, second)
+	}
+
+	t.Run("update job", func(t *testing.T) {
+		job, err := store.Update(first.ID, func(job *Job) {
+			job.State = JobStateSucceeded
+			job.Attempts++
+			job.OstrichCommitID = "1111111"
+		})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		job, err = store.Get(first.ID)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if job.State != JobStateSucceeded || job.Attempts != 1 || job.OstrichCommitID != "1111111" {
+			t.Fatalf("invalid job %#v", job)
+		}
+		if job.Request.Repository != "https://github.com/x/y.git" {
+			t.Fatalf("invalid request %#v", job.Request)
+		}
+	})
+	t.Run("job not found", func(t *testing.T) {
+		if _, err := store.Get("999"); !errors.Is(err, ErrJobNotFound) {
+			t.Fatalf("invalid return %#v", err)
+		}
+		if _, err := store.Get("abc"); !errors.Is(err, ErrJobNotFound) {
+			t.Fatalf("invalid return %#v", err)
+		}
+		if _, err := store.Update("999", func(job *Job) {}); !errors.Is(err, ErrJobNotFound) {
+			t.Fatalf("invalid return %#v", err)
+		}
+	})
+	t.Run("list jobs", func(t *testing.T) {
+		jobs, err := store.List("", 0)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(jobs) != 2 || jobs[0].ID != second.ID {
+			t.Fatalf("invalid jobs %#v", jobs)
+		}
+		jobs, err = store.List(JobStateQueued, 0)
+		if err != nil || len(jobs) != 1 || jobs[0].ID != second.ID {
+			t.Fatalf("invalid jobs %#v, %#v", jobs, err)
+		}
+		jobs, err = store.List("", 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("invalid jobs %#v, %#v", jobs, err)
+		}
+	})
+	t.Run("reopen store", func(t *testing.T) {
+		if err := store.Close(); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		store, err = OpenJobStore(path)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		defer store.Close()
+		jobs, err := store.Unfinished()
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if len(jobs) != 1 || jobs[0].ID != second.ID {
+			t.Fatalf("invalid jobs %#v", jobs)
+		}
+		third, err := store.Create(OstrichWebRequest{})
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if third.ID == first.ID || third.ID == second.ID {
+			t.Fatalf("invalid job id %s", third.ID)
+		}
+	})
+}
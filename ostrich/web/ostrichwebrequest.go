@@ -1,8 +1,12 @@
 package web
 
 type OstrichWebRequest struct {
-	Repository    string `json:"repository"`
-	FromBranch    string `json:"fromBranch"`
-	CommitID      string `json:"commitId"`
-	OstrichBranch string `json:"ostrichBranch"`
+	Repository    string   `json:"repository"`
+	FromBranch    string   `json:"fromBranch"`
+	CommitID      string   `json:"commitId"`
+	CommitIDs     []string `json:"commitIds"`
+	CommitRange   string   `json:"commitRange"`
+	OstrichBranch string   `json:"ostrichBranch"`
+	DryRun        bool     `json:"dryRun"`
+	Accumulate    bool     `json:"accumulate"`
 }
@@ -1,5 +1,19 @@
 package web
 
 type OstrichWebResponse struct {
-	Message string `json:"message"`
+	Message string           `json:"message"`
+	JobID   string           `json:"jobId,omitempty"`
+	JobIDs  []string         `json:"jobIds,omitempty"` // jobs of webhook pushes
+	Diffs   []OstrichWebDiff `json:"diffs,omitempty"`
+}
+
+// JobListResponse is response of job list.
+type JobListResponse struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// OstrichWebDiff is ostrich diff of source commit in dry run.
+type OstrichWebDiff struct {
+	CommitID string `json:"commitId"`
+	Diff     string `json:"diff"`
 }
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is error when job queue has no space for requests.
+var ErrQueueFull = errors.New("job queue is full")
+
+// JobQueue is bounded queue of ostrich requests between web handlers and worker.
+// request is in queue until worker starts it, so requests waiting in worker pool keep space of queue.
+type JobQueue struct {
+	mutex    sync.Mutex
+	requests chan WebRequest
+	pending  int
+}
+
+// QueueStatus is depth and capacity of job queue.depth is requests not started.
+type QueueStatus struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+}
+
+// NewJobQueue is return queue with space of size requests.
+func NewJobQueue(size int) *JobQueue {
+	return &JobQueue{
+		requests: make(chan WebRequest, size),
+	}
+}
+
+// Requests is channel of queued requests for worker.
+func (q *JobQueue) Requests() <-chan WebRequest {
+	return q.requests
+}
+
+// Push is add all requests without waiting.returns ErrQueueFull and adds nothing when queue has no space for all.
+func (q *JobQueue) Push(requests ...WebRequest) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if cap(q.requests)-q.pending < len(requests) {
+		return ErrQueueFull
+	}
+	q.pending += len(requests)
+	for _, request := range requests {
+		q.requests <- request
+	}
+	return nil
+}
+
+// Started is remove a request started by worker from depth.
+func (q *JobQueue) Started() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.pending > 0 {
+		q.pending--
+	}
+}
+
+// Status is return current depth and capacity.
+func (q *JobQueue) Status() QueueStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return QueueStatus{
+		Depth:    q.pending,
+		Capacity: cap(q.requests),
+	}
+}
@@ -0,0 +1,52 @@
+package web
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJobQueue(t *testing.T) {
+	queue := NewJobQueue(2)
+	t.Run("push requests", func(t *testing.T) {
+		if err := queue.Push(WebRequest{JobID: "1"}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		status := queue.Status()
+		if status.Depth != 1 || status.Capacity != 2 {
+			t.Fatalf("invalid status %#v", status)
+		}
+	})
+	t.Run("no space for all requests", func(t *testing.T) {
+		if err := queue.Push(WebRequest{JobID: "2"}, WebRequest{JobID: "3"}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("invalid return %#v", err)
+		}
+		if depth := queue.Status().Depth; depth != 1 {
+			t.Fatalf("invalid depth %d", depth)
+		}
+	})
+	t.Run("queued order", func(t *testing.T) {
+		if err := queue.Push(WebRequest{JobID: "2"}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		for _, expect := range []string{"1", "2"} {
+			if request := <-queue.Requests(); request.JobID != expect {
+				t.Fatalf("invalid request %#v", request)
+			}
+		}
+	})
+	t.Run("received request keeps space until started", func(t *testing.T) {
+		if depth := queue.Status().Depth; depth != 2 {
+			t.Fatalf("invalid depth %d", depth)
+		}
+		if err := queue.Push(WebRequest{JobID: "3"}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("invalid return %#v", err)
+		}
+		queue.Started()
+		if err := queue.Push(WebRequest{JobID: "3"}); err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if depth := queue.Status().Depth; depth != 2 {
+			t.Fatalf("invalid depth %d", depth)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ErrPushSkipped is error when push is not processed.ex) tag, deleted branch, filtered branch
+var ErrPushSkipped = errors.New("push is skipped")
+
+// ErrEventIgnored is error when webhook event is not push.ex) ping
+var ErrEventIgnored = errors.New("event is ignored")
+
+// branchRefPrefix is prefix of branch ref in push event.
+const branchRefPrefix = "refs/heads/"
+
+// zeroCommitID is commit of not existing ref in push event.
+const zeroCommitID = "0000000000000000000000000000000000000000"
+
+// WebhookReceiver is webhook of git hosting service.
+// Verify is return error when request is not sent by service with secret.
+// Parse is return pushes in payload.not push event returns ErrEventIgnored.
+type WebhookReceiver interface {
+	Verify(secret string, header http.Header, body []byte) error
+	Parse(header http.Header, body []byte) ([]PushEvent, error)
+}
+
+// PushEvent is push in webhook payload of git hosting service.
+// CommitRange is used when payload has no commit list.
+type PushEvent struct {
+	Repository  string // clone url
+	Ref         string
+	CommitIDs   []string // pushed commits from old to new
+	CommitRange string
+	Deleted     bool
+	Truncated   bool // payload may not have all commits of created branch
+}
+
+// setCommits is use before..after as commit range of updated branch, so commits are chosen by rev-list same as range job.
+// commits of payload may be truncated and have merged commits.created branch has no before, so commits of payload are used.
+func (e *PushEvent) setCommits(before string, after string, commitIDs []string) {
+	e.CommitIDs = []string{}
+	e.CommitRange = ""
+	if e.Deleted || after == zeroCommitID {
+		return
+	}
+	if len(before) > 0 && before != zeroCommitID {
+		e.CommitRange = fmt.Sprintf("%s..%s", before, after)
+		return
+	}
+	e.CommitIDs = append(e.CommitIDs, commitIDs...)
+}
+
+// Branch is return branch name of ref.empty is not branch.
+func (e PushEvent) Branch() string {
+	if !strings.HasPrefix(e.Ref, branchRefPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(e.Ref, branchRefPrefix)
+}
+
+// WebhookConfig is setting of webhook endpoints.
+// Branches is glob of processed branches, and empty is all branches.
+// {BRANCH} in OstrichBranch is pushed branch.ex) ostrich-{BRANCH}
+type WebhookConfig struct {
+	Secret        string
+	Branches      []string
+	OstrichBranch string
+	Accumulate    bool
+}
+
+// MatchBranch is return true when branch matches Branches.
+func (c WebhookConfig) MatchBranch(branch string) bool {
+	if len(c.Branches) <= 0 {
+		return true
+	}
+	for _, pattern := range c.Branches {
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// NewOstrichWebRequest is return ostrich request of push.not processed push returns ErrPushSkipped.
+func (c WebhookConfig) NewOstrichWebRequest(event PushEvent) (OstrichWebRequest, error) {
+	branch := event.Branch()
+	if len(branch) <= 0 {
+		return OstrichWebRequest{}, fmt.Errorf("%w.%s is not branch", ErrPushSkipped, event.Ref)
+	}
+	if event.Deleted {
+		return OstrichWebRequest{}, fmt.Errorf("%w.%s is deleted", ErrPushSkipped, branch)
+	}
+	if !c.MatchBranch(branch) {
+		return OstrichWebRequest{}, fmt.Errorf("%w.%s is not matched branch filter", ErrPushSkipped, branch)
+	}
+	if event.Truncated {
+		return OstrichWebRequest{}, fmt.Errorf("%w.commits of created branch %s are not all in payload", ErrPushSkipped, branch)
+	}
+	if len(event.CommitIDs) <= 0 && len(event.CommitRange) <= 0 {
+		return OstrichWebRequest{}, fmt.Errorf("%w.%s has no commit", ErrPushSkipped, branch)
+	}
+	ostrichBranch := strings.Replace(c.OstrichBranch, "{BRANCH}", branch, -1)
+	if ostrichBranch == branch {
+		return OstrichWebRequest{}, fmt.Errorf("ostrich branch %s is same as pushed branch", ostrichBranch)
+	}
+	return OstrichWebRequest{
+		Repository:    event.Repository,
+		FromBranch:    branch,
+		CommitIDs:     event.CommitIDs,
+		CommitRange:   event.CommitRange,
+		OstrichBranch: ostrichBranch,
+		Accumulate:    c.Accumulate,
+	}, nil
+}
+
+// verifyHMACSHA256 is return error when hex signature is not HMAC-SHA256 of body by secret.
+func verifyHMACSHA256(secret string, body []byte, signature string) error {
+	if len(secret) <= 0 {
+		return errors.New("webhook secret is not configured")
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil || len(actual) <= 0 {
+		return errors.New("invalid webhook signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(actual, mac.Sum(nil)) {
+		return errors.New("webhook signature is not matched")
+	}
+	return nil
+}
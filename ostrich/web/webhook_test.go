@@ -0,0 +1,57 @@
+package web
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebhookConfigNewOstrichWebRequest(t *testing.T) {
+	config := WebhookConfig{
+		Branches:      []string{"master", "release/*"},
+		OstrichBranch: "ostrich-{BRANCH}",
+		Accumulate:    true,
+	}
+	event := PushEvent{
+		Repository: "https://github.com/miyatama/sample.git",
+		Ref:        "refs/heads/release/1.0",
+		CommitIDs:  []string{"1111111", "2222222"},
+	}
+	t.Run("matched branch", func(t *testing.T) {
+		request, err := config.NewOstrichWebRequest(event)
+		if err != nil {
+			t.Fatalf("return error %#v", err)
+		}
+		if request.FromBranch != "release/1.0" || request.OstrichBranch != "ostrich-release/1.0" {
+			t.Fatalf("invalid branch %s, %s", request.FromBranch, request.OstrichBranch)
+		}
+		if request.Repository != event.Repository || len(request.CommitIDs) != 2 || !request.Accumulate {
+			t.Fatalf("invalid request %#v", request)
+		}
+	})
+	skipEvents := map[string]PushEvent{
+		"tag":             {Repository: event.Repository, Ref: "refs/tags/v1.0", CommitIDs: event.CommitIDs},
+		"deleted":         {Repository: event.Repository, Ref: "refs/heads/master", Deleted: true},
+		"filtered branch": {Repository: event.Repository, Ref: "refs/heads/feature/x", CommitIDs: event.CommitIDs},
+		"no commit":       {Repository: event.Repository, Ref: "refs/heads/master", CommitIDs: []string{}},
+	}
+	for name, skipEvent := range skipEvents {
+		t.Run(name, func(t *testing.T) {
+			if _, err := config.NewOstrichWebRequest(skipEvent); !errors.Is(err, ErrPushSkipped) {
+				t.Fatalf("not return skipped error %#v", err)
+			}
+		})
+	}
+	t.Run("no branch filter", func(t *testing.T) {
+		allConfig := WebhookConfig{OstrichBranch: "ostrich"}
+		if !allConfig.MatchBranch("feature/x") {
+			t.Fatal("branch is not matched")
+		}
+	})
+	t.Run("same branch", func(t *testing.T) {
+		sameConfig := WebhookConfig{OstrichBranch: "{BRANCH}"}
+		_, err := sameConfig.NewOstrichWebRequest(event)
+		if err == nil || errors.Is(err, ErrPushSkipped) {
+			t.Fatalf("invalid return %#v", err)
+		}
+	})
+}
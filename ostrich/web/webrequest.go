@@ -1,9 +1,9 @@
 package web
 
-
 type WebRequest struct {
 	Action WebAction
-	Info OstrichWebRequest
+	JobID  string
+	Info   OstrichWebRequest
 }
 
 type WebAction int
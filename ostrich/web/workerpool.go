@@ -0,0 +1,112 @@
+package web
+
+import (
+	"sync"
+)
+
+// WorkerPool is running requests by Workers goroutines in parallel.
+// requests of same repository and ostrich branch are run one by one in queued order, so ostrich branch history keeps order.
+// Started is called when request is sent to worker, if it is set.
+type WorkerPool struct {
+	Workers int
+	Handle  func(request WebRequest)
+	Started func()
+
+	mutex  sync.Mutex
+	status WorkerStatus
+}
+
+// WorkerStatus is running requests and requests waiting for running request of same ostrich branch.
+type WorkerStatus struct {
+	Workers int `json:"workers"`
+	Running int `json:"running"`
+	Waiting int `json:"waiting"`
+}
+
+// serialKey is key of requests which are not run in parallel.dry run does not push, so it is not serialized.
+func serialKey(request WebRequest) string {
+	if request.Info.DryRun {
+		return "dry run\n" + request.JobID
+	}
+	return request.Info.Repository + "\n" + request.Info.OstrichBranch
+}
+
+// Run is run requests until requests is closed or done request is received, and wait running requests.
+// requests are received while other requests wait, so waiting requests do not block requests of other ostrich branches.
+func (p *WorkerPool) Run(requests <-chan WebRequest) {
+	ready := make(chan WebRequest)
+	done := make(chan string)
+	wait := sync.WaitGroup{}
+	for i := 0; i < p.Workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for request := range ready {
+				p.Handle(request)
+				done <- serialKey(request)
+			}
+		}()
+	}
+
+	runnable := []WebRequest{}
+	waiting := map[string][]WebRequest{}
+	active := map[string]bool{} // key of runnable or running request
+	waitingCount := 0
+	running := 0
+	for requests != nil || len(active) > 0 {
+		p.setStatus(WorkerStatus{Workers: p.Workers, Running: running, Waiting: waitingCount})
+		var send chan WebRequest
+		var next WebRequest
+		if len(runnable) > 0 {
+			send = ready
+			next = runnable[0]
+		}
+		select {
+		case request, ok := <-requests:
+			if !ok || request.Action == WebRequestActionDone {
+				requests = nil
+				continue
+			}
+			key := serialKey(request)
+			if active[key] {
+				waiting[key] = append(waiting[key], request)
+				waitingCount++
+				continue
+			}
+			active[key] = true
+			runnable = append(runnable, request)
+		case send <- next:
+			runnable = runnable[1:]
+			running++
+			if p.Started != nil {
+				p.Started()
+			}
+		case key := <-done:
+			running--
+			if len(waiting[key]) <= 0 {
+				delete(waiting, key)
+				delete(active, key)
+				continue
+			}
+			runnable = append(runnable, waiting[key][0])
+			waiting[key] = waiting[key][1:]
+			waitingCount--
+		}
+	}
+	close(ready)
+	wait.Wait()
+	p.setStatus(WorkerStatus{Workers: p.Workers})
+}
+
+// Status is return current running and waiting requests.
+func (p *WorkerPool) Status() WorkerStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.status
+}
+
+func (p *WorkerPool) setStatus(status WorkerStatus) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.status = status
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	request := func(jobID string, repository string) WebRequest {
+		return WebRequest{
+			Action: WebRequestActionOstrich,
+			JobID:  jobID,
+			Info:   OstrichWebRequest{Repository: repository, OstrichBranch: "ostrich"},
+		}
+	}
+	mutex := sync.Mutex{}
+	order := []string{}
+	running := map[string]bool{}
+	parallel := false
+	otherStarted := make(chan bool, 1)
+	pool := &WorkerPool{
+		Workers: 2,
+		Handle: func(request WebRequest) {
+			mutex.Lock()
+			if running[request.Info.Repository] {
+				t.Errorf("job %s runs with job of same ostrich branch", request.JobID)
+			}
+			running[request.Info.Repository] = true
+			order = append(order, request.JobID)
+			mutex.Unlock()
+
+			switch request.JobID {
+			case "1":
+				// other repository runs while first job is running
+				select {
+				case <-otherStarted:
+					parallel = true
+				case <-time.After(5 * time.Second):
+				}
+			case "3":
+				otherStarted <- true
+			}
+
+			mutex.Lock()
+			running[request.Info.Repository] = false
+			mutex.Unlock()
+		},
+	}
+	requests := make(chan WebRequest, 4)
+	requests <- request("1", "https://github.com/x/y.git")
+	requests <- request("2", "https://github.com/x/y.git")
+	requests <- request("3", "https://github.com/x/z.git")
+	requests <- WebRequest{Action: WebRequestActionDone}
+	pool.Run(requests)
+
+	if !parallel {
+		t.Fatal("jobs of other repository are not run in parallel")
+	}
+	if len(order) != 3 || order[2] != "2" {
+		t.Fatalf("invalid order %#v", order)
+	}
+	if status := pool.Status(); status.Running != 0 || status.Waiting != 0 {
+		t.Fatalf("invalid status %#v", status)
+	}
+}
+
+func TestWorkerPoolWaitingRequests(t *testing.T) {
+	request := func(jobID string, repository string) WebRequest {
+		return WebRequest{
+			Action: WebRequestActionOstrich,
+			JobID:  jobID,
+			Info:   OstrichWebRequest{Repository: repository, OstrichBranch: "ostrich"},
+		}
+	}
+	mutex := sync.Mutex{}
+	order := []string{}
+	started := 0
+	otherStarted := make(chan bool, 1)
+	parallel := false
+	pool := &WorkerPool{
+		Workers: 2,
+		Handle: func(request WebRequest) {
+			mutex.Lock()
+			order = append(order, request.JobID)
+			mutex.Unlock()
+			switch request.JobID {
+			case "1":
+				// other repository runs while more than Workers jobs wait for first job
+				select {
+				case <-otherStarted:
+					parallel = true
+				case <-time.After(5 * time.Second):
+				}
+			case "5":
+				otherStarted <- true
+			}
+		},
+		Started: func() {
+			mutex.Lock()
+			started++
+			mutex.Unlock()
+		},
+	}
+	requests := make(chan WebRequest, 6)
+	for _, jobID := range []string{"1", "2", "3", "4"} {
+		requests <- request(jobID, "https://github.com/x/y.git")
+	}
+	requests <- request("5", "https://github.com/x/z.git")
+	requests <- WebRequest{Action: WebRequestActionDone}
+	pool.Run(requests)
+
+	if !parallel {
+		t.Fatal("job of other repository waits for jobs of same ostrich branch")
+	}
+	same := []string{}
+	for _, jobID := range order {
+		if jobID != "5" {
+			same = append(same, jobID)
+		}
+	}
+	if len(order) != 5 || strings.Join(same, ",") != "1,2,3,4" {
+		t.Fatalf("invalid order %#v", order)
+	}
+	if started != 5 {
+		t.Fatalf("invalid started %d", started)
+	}
+}
+
+func TestWorkerPoolDryRun(t *testing.T) {
+	info := OstrichWebRequest{Repository: "https://github.com/x/y.git", OstrichBranch: "ostrich"}
+	dryRunInfo := info
+	dryRunInfo.DryRun = true
+	dryRunStarted := make(chan bool, 1)
+	parallel := false
+	pool := &WorkerPool{
+		Workers: 2,
+		Handle: func(request WebRequest) {
+			switch request.JobID {
+			case "1":
+				// dry run of same ostrich branch runs while first job is running
+				select {
+				case <-dryRunStarted:
+					parallel = true
+				case <-time.After(5 * time.Second):
+				}
+			case "2":
+				dryRunStarted <- true
+			}
+		},
+	}
+	requests := make(chan WebRequest, 3)
+	requests <- WebRequest{Action: WebRequestActionOstrich, JobID: "1", Info: info}
+	requests <- WebRequest{Action: WebRequestActionOstrich, JobID: "2", Info: dryRunInfo}
+	requests <- WebRequest{Action: WebRequestActionDone}
+	pool.Run(requests)
+
+	if !parallel {
+		t.Fatal("dry run waits for job of same ostrich branch")
+	}
+}
@@ -0,0 +1,57 @@
+package ostrich
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// cleanup policy of job workspace
+const (
+	WorkspaceCleanupAlways    = "always"
+	WorkspaceCleanupOnSuccess = "success"
+	WorkspaceCleanupNever     = "never"
+)
+
+// ValidateWorkspaceCleanup is return error when policy is unknown.empty is WorkspaceCleanupAlways.
+func ValidateWorkspaceCleanup(policy string) error {
+	switch policy {
+	case "", WorkspaceCleanupAlways, WorkspaceCleanupOnSuccess, WorkspaceCleanupNever:
+		return nil
+	}
+	return fmt.Errorf("invalid workspace cleanup %s.always, success or never", policy)
+}
+
+// createWorkspace is create temporary directory of job in workspace root.
+func (o *Ostrich) createWorkspace() (string, error) {
+	root := o.WorkspaceRoot
+	if len(root) <= 0 {
+		root = os.TempDir()
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+	workspace, err := ioutil.TempDir(root, "ostrich-")
+	if err != nil {
+		return "", err
+	}
+	o.outputDebug(fmt.Sprintf("workspace: %s", workspace))
+	return workspace, nil
+}
+
+// removeWorkspace is remove workspace by cleanup policy.failed workspace is kept for investigation by policy.
+func (o *Ostrich) removeWorkspace(workspace string, succeeded bool) {
+	switch o.WorkspaceCleanup {
+	case WorkspaceCleanupNever:
+		return
+	case WorkspaceCleanupOnSuccess:
+		if !succeeded {
+			log.Printf("[WARN]: workspace is kept: %s", workspace)
+			return
+		}
+	}
+	if err := os.RemoveAll(workspace); err != nil {
+		log.Printf("[WARN]: can not remove workspace %s.%s", workspace, err.Error())
+	}
+}